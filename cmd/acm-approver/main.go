@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/cli"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/handler"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
+)
+
+func main() {
+	logger := handler.NewLogger()
+
+	if err := tracing.Init(); err != nil {
+		logger.Fatal().Err(err).Msg("failed to configure tracing")
+	}
+
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background()))
+	defer cancel()
+
+	// stop waiting on ACM and route53 when interrupted
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	err := cli.New(os.Stdout, os.Stderr).Run(ctx, os.Args[1:])
+
+	// os.Exit skips deferred calls so spans are flushed before checking the result
+	tracing.Flush()
+
+	if err == cli.ErrUsage {
+		os.Exit(2)
+	}
+
+	if err != nil {
+		logger.Fatal().Err(err).Msg("command failed")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/handler"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/watchdog"
+)
+
+func main() {
+	logger := handler.NewLogger()
+
+	logger.Info().Msg("starting lambda")
+
+	if err := tracing.Init(); err != nil {
+		logger.Fatal().Err(err).Msg("failed to configure tracing")
+	}
+
+	wd := watchdog.New().WithLogger(logger)
+
+	lambda.Start(func(ctx context.Context, event events.CloudWatchEvent) error {
+		// spans are flushed before lambda freezes the process
+		defer tracing.Flush()
+
+		return wd.Handle(ctx, event)
+	})
+}
@@ -1,17 +1,30 @@
 package main
 
 import (
-	"github.com/aws/aws-lambda-go/cfn"
+	"context"
+	"encoding/json"
+
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/rs/zerolog/log"
 
 	"github.com/wolfeidau/serverless-acm-approver/pkg/handler"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
 )
 
 func main() {
-	log.Info().Msg("starting lambda")
+	logger := handler.NewLogger()
+
+	logger.Info().Msg("starting lambda")
+
+	if err := tracing.Init(); err != nil {
+		logger.Fatal().Err(err).Msg("failed to configure tracing")
+	}
+
+	dispatcher := handler.New().WithLogger(logger)
 
-	dispatcher := handler.New()
+	lambda.Start(func(ctx context.Context, payload json.RawMessage) error {
+		// spans are flushed before lambda freezes the process
+		defer tracing.Flush()
 
-	lambda.Start(cfn.LambdaWrap(dispatcher.CreateAndApproveACMCertificate))
+		return dispatcher.Handle(ctx, payload)
+	})
 }
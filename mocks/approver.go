@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	gomock "github.com/golang/mock/gomock"
+	approver "github.com/wolfeidau/serverless-acm-approver/pkg/approver"
 	reflect "reflect"
 )
 
@@ -34,7 +35,7 @@ func (m *MockCertificate) EXPECT() *MockCertificateMockRecorder {
 }
 
 // Approve mocks base method
-func (m *MockCertificate) Approve(arg0 context.Context, arg1, arg2 string) error {
+func (m *MockCertificate) Approve(arg0 context.Context, arg1 string, arg2 approver.Zones) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Approve", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
@@ -48,30 +49,206 @@ func (mr *MockCertificateMockRecorder) Approve(arg0, arg1, arg2 interface{}) *go
 }
 
 // Delete mocks base method
-func (m *MockCertificate) Delete(arg0 context.Context, arg1 string) error {
+func (m *MockCertificate) Delete(arg0 context.Context, arg1 string, arg2 approver.Zones, arg3 approver.InUsePolicy) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockCertificateMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCertificateMockRecorder) Delete(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCertificate)(nil).Delete), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCertificate)(nil).Delete), arg0, arg1, arg2, arg3)
+}
+
+// FindIssued mocks base method
+func (m *MockCertificate) FindIssued(arg0 context.Context, arg1 string, arg2 []string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIssued", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIssued indicates an expected call of FindIssued
+func (mr *MockCertificateMockRecorder) FindIssued(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIssued", reflect.TypeOf((*MockCertificate)(nil).FindIssued), arg0, arg1, arg2)
+}
+
+// ListManaged mocks base method
+func (m *MockCertificate) ListManaged(arg0 context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListManaged", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListManaged indicates an expected call of ListManaged
+func (mr *MockCertificateMockRecorder) ListManaged(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListManaged", reflect.TypeOf((*MockCertificate)(nil).ListManaged), arg0)
+}
+
+// PlanApprove mocks base method
+func (m *MockCertificate) PlanApprove(arg0 context.Context, arg1 string, arg2 approver.Zones) (*approver.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanApprove", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*approver.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanApprove indicates an expected call of PlanApprove
+func (mr *MockCertificateMockRecorder) PlanApprove(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanApprove", reflect.TypeOf((*MockCertificate)(nil).PlanApprove), arg0, arg1, arg2)
+}
+
+// PlanDelete mocks base method
+func (m *MockCertificate) PlanDelete(arg0 context.Context, arg1 string, arg2 approver.Zones, arg3 approver.InUsePolicy) (*approver.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanDelete", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*approver.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanDelete indicates an expected call of PlanDelete
+func (mr *MockCertificateMockRecorder) PlanDelete(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanDelete", reflect.TypeOf((*MockCertificate)(nil).PlanDelete), arg0, arg1, arg2, arg3)
+}
+
+// PlanRequest mocks base method
+func (m *MockCertificate) PlanRequest(arg0 context.Context, arg1 string, arg2 []string, arg3 approver.Zones) (*approver.Plan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanRequest", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*approver.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanRequest indicates an expected call of PlanRequest
+func (mr *MockCertificateMockRecorder) PlanRequest(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanRequest", reflect.TypeOf((*MockCertificate)(nil).PlanRequest), arg0, arg1, arg2, arg3)
+}
+
+// Preflight mocks base method
+func (m *MockCertificate) Preflight(arg0 context.Context, arg1 string, arg2 []string, arg3 approver.Zones) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preflight", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Preflight indicates an expected call of Preflight
+func (mr *MockCertificateMockRecorder) Preflight(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preflight", reflect.TypeOf((*MockCertificate)(nil).Preflight), arg0, arg1, arg2, arg3)
+}
+
+// Publish mocks base method
+func (m *MockCertificate) Publish(arg0 context.Context, arg1 string, arg2 approver.Zones) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish
+func (mr *MockCertificateMockRecorder) Publish(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockCertificate)(nil).Publish), arg0, arg1, arg2)
+}
+
+// Repair mocks base method
+func (m *MockCertificate) Repair(arg0 context.Context, arg1 string, arg2 approver.Zones) ([]approver.RecordRepair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Repair", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]approver.RecordRepair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Repair indicates an expected call of Repair
+func (mr *MockCertificateMockRecorder) Repair(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*MockCertificate)(nil).Repair), arg0, arg1, arg2)
 }
 
 // Request mocks base method
-func (m *MockCertificate) Request(arg0 context.Context, arg1, arg2 string, arg3 []string) (string, error) {
+func (m *MockCertificate) Request(arg0 context.Context, arg1, arg2 string, arg3 []string, arg4 map[string]string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Request", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "Request", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Request indicates an expected call of Request
-func (mr *MockCertificateMockRecorder) Request(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockCertificateMockRecorder) Request(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockCertificate)(nil).Request), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Status mocks base method
+func (m *MockCertificate) Status(arg0 context.Context, arg1 string) (*approver.CertificateStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", arg0, arg1)
+	ret0, _ := ret[0].(*approver.CertificateStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status
+func (mr *MockCertificateMockRecorder) Status(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockCertificate)(nil).Status), arg0, arg1)
+}
+
+// Tag mocks base method
+func (m *MockCertificate) Tag(arg0 context.Context, arg1 string, arg2 map[string]string, arg3 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Tag indicates an expected call of Tag
+func (mr *MockCertificateMockRecorder) Tag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tag", reflect.TypeOf((*MockCertificate)(nil).Tag), arg0, arg1, arg2, arg3)
+}
+
+// Tags mocks base method
+func (m *MockCertificate) Tags(arg0 context.Context, arg1 string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tags", arg0, arg1)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tags indicates an expected call of Tags
+func (mr *MockCertificateMockRecorder) Tags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockCertificate)(nil).Tags), arg0, arg1)
+}
+
+// WaitForValidation mocks base method
+func (m *MockCertificate) WaitForValidation(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForValidation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForValidation indicates an expected call of WaitForValidation
+func (mr *MockCertificateMockRecorder) WaitForValidation(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockCertificate)(nil).Request), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForValidation", reflect.TypeOf((*MockCertificate)(nil).WaitForValidation), arg0, arg1)
 }
@@ -4,118 +4,313 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/acm"
 	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
 )
 
 const (
-	maxAttempts          = 20
-	recordTTLSeconds     = 60
-	describePollWaitTime = 5 * time.Second
-	validationPollTime   = 30 * time.Second
-	deletionPollTime     = 30 * time.Second
+	deadlineReserve = 10 * time.Second
 )
 
+// ErrRecordsNotGenerated returned when ACM hasn't generated the validation record for every domain of
+// the certificate before the describe attempts run out, it belongs to the ErrTimeout class
+var ErrRecordsNotGenerated error = &classifiedError{msg: "ACM did not generate the validation records", class: ErrTimeout}
+
+// keyTypes all the key algorithms, by default ACM only lists RSA_2048 certificates
+var keyTypes = []string{
+	acm.KeyAlgorithmRsa1024,
+	acm.KeyAlgorithmRsa2048,
+	acm.KeyAlgorithmRsa4096,
+	acm.KeyAlgorithmEcPrime256v1,
+	acm.KeyAlgorithmEcSecp384r1,
+	acm.KeyAlgorithmEcSecp521r1,
+}
+
 // Certificate AWS ACM approver
 type Certificate interface {
-	Approve(ctx context.Context, certificateArn string, hostedZoneID string) error
-	Request(ctx context.Context, requestID string, domainName string, subjectAlternativeNames []string) (string, error)
-	Delete(ctx context.Context, certificateArn string) error
+	// Approve publishes the validation records for the certificate into the hosted zones selected
+	// by zones and waits for it to be issued
+	Approve(ctx context.Context, certificateArn string, zones Zones) error
+	// Publish waits for ACM to generate the validation records for the certificate then publishes
+	// them into the hosted zones selected by zones
+	Publish(ctx context.Context, certificateArn string, zones Zones) error
+	// WaitForValidation waits for the certificate to be issued, a ValidationError is returned if ACM
+	// fails to validate it
+	WaitForValidation(ctx context.Context, certificateArn string) error
+	// Preflight checks the names are within the public hosted zones selected by zones, and their CAA
+	// records allow ACM to issue a certificate for them, before it is requested
+	Preflight(ctx context.Context, domainName string, subjectAlternativeNames []string, zones Zones) error
+	// Request a new certificate with the supplied tags, the request id is used to ensure only one
+	// certificate is requested for each cloudformation request
+	Request(ctx context.Context, requestID string, domainName string, subjectAlternativeNames []string, tags map[string]string) (string, error)
+	// FindIssued returns the arn of an issued certificate covering exactly the domain name and subject
+	// alternative names, or an empty string if there isn't one
+	FindIssued(ctx context.Context, domainName string, subjectAlternativeNames []string) (string, error)
+	// Status describes the certificate, including whether ACM is able to renew it
+	Status(ctx context.Context, certificateArn string) (*CertificateStatus, error)
+	// Tags returns the tags on a certificate
+	Tags(ctx context.Context, certificateArn string) (map[string]string, error)
+	// Tag adds or updates the tags on a certificate and removes any tags with the listed keys
+	Tag(ctx context.Context, certificateArn string, tags map[string]string, removeKeys []string) error
+	// ListManaged returns the arns of the certificates tagged as requested by the approver
+	ListManaged(ctx context.Context) ([]string, error)
+	// Repair compares the validation records of the certificate with those in the hosted zones
+	// selected by zones, restoring any which are missing or changed
+	Repair(ctx context.Context, certificateArn string, zones Zones) ([]RecordRepair, error)
+	// PlanRequest runs the pre-flight checks and resolves the hosted zones for the names, returning the
+	// calls Request and Approve would make without making them
+	PlanRequest(ctx context.Context, domainName string, subjectAlternativeNames []string, zones Zones) (*Plan, error)
+	// PlanApprove compares the validation records of the certificate with the hosted zones, returning
+	// the records Approve would upsert without changing them
+	PlanApprove(ctx context.Context, certificateArn string, zones Zones) (*Plan, error)
+	// PlanDelete returns the calls Delete would make, and the validation records it would remove,
+	// without making them
+	PlanDelete(ctx context.Context, certificateArn string, zones Zones, policy InUsePolicy) (*Plan, error)
+	// Delete removes the certificate once it is no longer in use along with any validation
+	// records which aren't referenced by other certificates, policy controls what happens if
+	// the certificate remains in use
+	Delete(ctx context.Context, certificateArn string, zones Zones, policy InUsePolicy) error
+}
+
+// Zones selects the hosted zones which validation records are published into
+type Zones struct {
+	// HostedZoneID is used for records which don't match an entry in Domains, when this is
+	// empty the public hosted zone for the record is discovered
+	HostedZoneID string
+	// Domains maps a domain name, or a suffix of one, to the id of the hosted zone which holds
+	// its validation records, this supports certificates with names spanning multiple zones
+	Domains map[string]string
+	// PreferredHostedZoneID is used for records within that hosted zone which don't match an entry in
+	// Domains, other records are discovered, this is used when the zones selected for a certificate
+	// aren't known
+	PreferredHostedZoneID string
+}
+
+// InUsePolicy controls what Delete does with a certificate which remains in use by other resources
+type InUsePolicy string
+
+const (
+	// InUseFail waits for the certificate to be released then fails with an InUseError, this is the default
+	InUseFail InUsePolicy = "Fail"
+	// InUseRetain waits for the certificate to be released then leaves it in place
+	InUseRetain InUsePolicy = "Retain"
+	// InUseWait waits for as long as the context allows before failing with an InUseError
+	InUseWait InUsePolicy = "Wait"
+)
+
+// InUseError returned when a certificate is still in use by other resources and can't be deleted
+type InUseError struct {
+	CertificateArn string
+	InUseBy        []string
+}
+
+func (e *InUseError) Error() string {
+	return fmt.Sprintf("certificate %s is still in use by %d resources: %s",
+		e.CertificateArn, len(e.InUseBy), strings.Join(e.InUseBy, ", "))
 }
 
 // Approver the ACM approver
 type certificateApprover struct {
-	acm     acmiface.ACMAPI
-	route53 route53iface.Route53API
+	acm         acmiface.ACMAPI
+	route53     route53iface.Route53API
+	provider    dns.Provider
+	regionalACM regionalClients
+	timing      timing
+	clock       Clock
+	logger      zerolog.Logger
+	metrics     *metrics.Metrics
 }
 
 // New creates a new approver
-func New(config ...*aws.Config) Certificate {
-	sess := session.Must(session.NewSession(config...))
+func New(opts ...Option) Certificate {
+	o := newOptions(opts...)
+
+	sess := session.Must(session.NewSession(o.configs...))
+
+	acmsvc := acm.New(sess)
+	route53svc := route53.New(sess)
 
-	return &certificateApprover{
-		acm:     acm.New(sess),
-		route53: route53.New(sess),
+	if o.route53RoleArn != "" {
+		creds := stscreds.NewCredentials(sess, o.route53RoleArn, func(p *stscreds.AssumeRoleProvider) {
+			if o.route53ExternalID != "" {
+				p.ExternalID = aws.String(o.route53ExternalID)
+			}
+		})
+
+		route53svc = route53.New(sess, aws.NewConfig().WithCredentials(creds))
+
+		// with clients in two accounts make it clear which one failed
+		describeErrors(&acmsvc.Handlers, "acm request in the local account failed")
+		describeErrors(&route53svc.Handlers, fmt.Sprintf("route53 request using role %s failed", o.route53RoleArn))
 	}
+
+	// classify errors so throttling and invalid input can be identified with errors.Is
+	classifyErrors(&acmsvc.Handlers)
+	classifyErrors(&route53svc.Handlers)
+
+	// each request is traced after it is classified so spans record the final error
+	tracing.AddHandlers(&acmsvc.Handlers)
+	tracing.AddHandlers(&route53svc.Handlers)
+
+	return withTracing(&certificateApprover{
+		acm:         acmsvc,
+		route53:     route53svc,
+		provider:    o.provider,
+		regionalACM: newRegionalClients(sess, ec2.New(sess)),
+		timing:      o.timing,
+		clock:       o.clock,
+		logger:      o.logger,
+		metrics:     o.metrics,
+	})
+}
+
+// describeErrors adds a description of the client to any error returned by a request
+func describeErrors(handlers *request.Handlers, description string) {
+	handlers.Complete.PushBack(func(r *request.Request) {
+		if r.Error != nil {
+			r.Error = errors.Wrap(r.Error, description)
+		}
+	})
+}
+
+func (ac *certificateApprover) Approve(ctx context.Context, certificateArn string, zones Zones) error {
+	err := ac.Publish(ctx, certificateArn, zones)
+	if err != nil {
+		return err
+	}
+
+	return ac.WaitForValidation(ctx, certificateArn)
+}
+
+func (ac *certificateApprover) Publish(ctx context.Context, certificateArn string, zones Zones) (err error) {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
+
+	start := ac.clock.Now()
+	defer func() { ac.recordDuration(metricPublishLatency, "Publish", start, err) }()
+
+	var validations []*acm.DomainValidation
+
+	err = ac.runPhase(ctx, "describing validation records", describeShare, func(ctx context.Context) (err error) {
+		validations, err = ac.describeValidationRecords(ctx, certificateArn)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return ac.runPhase(ctx, "publishing validation records", publishShare, func(ctx context.Context) error {
+		return ac.publishRecords(ctx, zones, validations)
+	})
 }
 
-func (ac *certificateApprover) Approve(ctx context.Context, certificateArn string, hostedZoneID string) error {
-	var (
-		err error
-		res *acm.DescribeCertificateOutput
-	)
+func (ac *certificateApprover) WaitForValidation(ctx context.Context, certificateArn string) error {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
 
-	for i := 1; i < maxAttempts; i++ {
-		log.Info().Str("certificateArn", certificateArn).Msg("describe certificate")
+	return ac.runPhase(ctx, "waiting for certificate validation", validationShare, func(ctx context.Context) error {
+		zerolog.Ctx(ctx).Info().Msg("waiting for certificate validation")
 
-		res, err = ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+		return ac.waitForIssued(ctx, certificateArn)
+	})
+}
+
+// describeValidationRecords polls the certificate until ACM has generated the validation record for
+// every domain, ErrRecordsNotGenerated is returned if any are still missing after the max attempts
+func (ac *certificateApprover) describeValidationRecords(ctx context.Context, certificateArn string) ([]*acm.DomainValidation, error) {
+	for i := 1; ; i++ {
+		zerolog.Ctx(ctx).Info().Msg("describe certificate")
+
+		res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
 			CertificateArn: aws.String(certificateArn),
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if len(res.Certificate.DomainValidationOptions) > 0 {
-			if res.Certificate.DomainValidationOptions[0].ResourceRecord != nil {
-				log.Info().Str("certificateArn", certificateArn).Msg("certificate contains confirmation record")
-				break
-			}
+		validations := res.Certificate.DomainValidationOptions
+
+		pending := pendingDomains(validations)
+		if len(validations) > 0 && len(pending) == 0 {
+			zerolog.Ctx(ctx).Info().Msg("certificate contains confirmation record")
+			ac.recordRetries("DescribeValidationRecords", i)
+			return validations, nil
 		}
 
-		time.Sleep(describePollWaitTime)
-	}
-
-	for _, validation := range res.Certificate.DomainValidationOptions {
-		record := validation.ResourceRecord
-
-		log.Info().Msgf("Upserting DNS record into zone %s: %s %s %s",
-			hostedZoneID, aws.StringValue(record.Name), aws.StringValue(record.Type), aws.StringValue(record.Value))
-
-		_, err = ac.route53.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
-			HostedZoneId: aws.String(hostedZoneID),
-			ChangeBatch: &route53.ChangeBatch{Changes: []*route53.Change{
-				{
-					Action: aws.String(route53.ChangeActionUpsert),
-					ResourceRecordSet: &route53.ResourceRecordSet{
-						Name: record.Name,
-						Type: record.Type,
-						TTL:  aws.Int64(recordTTLSeconds),
-						ResourceRecords: []*route53.ResourceRecord{
-							{
-								Value: record.Value,
-							},
-						},
-					},
-				},
-			}},
-		})
+		if i >= ac.timing.maxAttempts {
+			ac.recordRetries("DescribeValidationRecords", i)
+			return nil, errors.Wrapf(ErrRecordsNotGenerated, "certificate %s is missing records for %s after %d attempts",
+				certificateArn, strings.Join(pending, ", "), i)
+		}
+
+		err = ac.clock.Sleep(ctx, ac.timing.pollDelay(ac.timing.describePollTime, i))
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
+}
+
+// pendingDomains returns the domains which ACM hasn't generated a validation record for yet
+func pendingDomains(validations []*acm.DomainValidation) []string {
+	pending := []string{}
+
+	for _, domainValidation := range validations {
+		if domainValidation.ResourceRecord == nil {
+			pending = append(pending, aws.StringValue(domainValidation.DomainName))
+		}
+	}
+
+	return pending
+}
+
+// publishRecords upserts the validation records into their zones and waits for the changes to propagate
+func (ac *certificateApprover) publishRecords(ctx context.Context, zones Zones, validations []*acm.DomainValidation) error {
+	if pending := pendingDomains(validations); len(pending) > 0 {
+		return errors.Wrapf(ErrRecordsNotGenerated, "missing records for %s", strings.Join(pending, ", "))
+	}
 
-	log.Info().Str("certificateArn", certificateArn).Msg("waiting for certificate validation")
+	provider := ac.dnsProvider(zones)
 
-	err = ac.acm.WaitUntilCertificateValidatedWithContext(ctx, &acm.DescribeCertificateInput{
-		CertificateArn: res.Certificate.CertificateArn,
-	}, request.WithWaiterMaxAttempts(maxAttempts), request.WithWaiterDelay(request.ConstantWaiterDelay(validationPollTime)))
+	grouped, err := groupRecordsByZone(ctx, provider, validations)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if len(grouped) == 0 {
+		return nil
+	}
+
+	changes := []dns.Change{}
+
+	for _, zr := range grouped {
+		zr.changes, err = provider.Upsert(ctx, zr.hostedZoneID, zr.records)
+		if err != nil {
+			return err
+		}
+
+		changes = append(changes, zr.changes...)
+	}
+
+	return provider.Wait(ctx, changes)
 }
 
-func (ac *certificateApprover) Request(ctx context.Context, requestID, domainName string, subjectAlternativeNames []string) (string, error) {
+func (ac *certificateApprover) Request(ctx context.Context, requestID, domainName string, subjectAlternativeNames []string, tags map[string]string) (string, error) {
+	ctx = ac.withLogger(ctx)
+
 	// unique hash of cloudformation request id to ensure only one
 	// certificate is created for this CFN request
 	token := sum(requestID)
@@ -126,12 +321,16 @@ func (ac *certificateApprover) Request(ctx context.Context, requestID, domainNam
 		IdempotencyToken: aws.String(token),
 	}
 
-	log.Info().Strs("subjectAlternativeNames", subjectAlternativeNames).Str("token", token).Msg("Request Certificate")
+	zerolog.Ctx(ctx).Info().Strs("subjectAlternativeNames", subjectAlternativeNames).Str("token", token).Msg("Request Certificate")
 
 	if len(subjectAlternativeNames) > 0 {
 		input.SubjectAlternativeNames = aws.StringSlice(subjectAlternativeNames)
 	}
 
+	if len(tags) > 0 {
+		input.Tags = acmTags(tags)
+	}
+
 	res, err := ac.acm.RequestCertificateWithContext(ctx, input)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to Request Certificate")
@@ -139,38 +338,191 @@ func (ac *certificateApprover) Request(ctx context.Context, requestID, domainNam
 
 	certificateArn := aws.StringValue(res.CertificateArn)
 
-	log.Info().Str("arn", certificateArn).Msg("requested certificate")
+	zerolog.Ctx(ctx).Info().Str("certificateArn", certificateArn).Msg("requested certificate")
 
 	return certificateArn, nil
 }
 
-func (ac *certificateApprover) Delete(ctx context.Context, certificateArn string) error {
-	log.Info().Str("certificateArn", certificateArn).Msg("Delete waiting for InUseBy of 0")
+func (ac *certificateApprover) Delete(ctx context.Context, certificateArn string, zones Zones, policy InUsePolicy) error {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
+
+	res, err := ac.waitUntilNotInUse(ctx, certificateArn, policy)
+	if err != nil {
+		return err
+	}
+
+	if len(res.Certificate.InUseBy) > 0 {
+		inUseErr := &InUseError{CertificateArn: certificateArn, InUseBy: aws.StringValueSlice(res.Certificate.InUseBy)}
 
-	for i := 1; i < maxAttempts; i++ {
+		if policy == InUseRetain {
+			zerolog.Ctx(ctx).Warn().Strs("InUseBy", inUseErr.InUseBy).Msg("certificate is still in use, retaining it")
+			return nil
+		}
+
+		return inUseErr
+	}
+
+	zerolog.Ctx(ctx).Info().Msg("deleting certificate")
+
+	_, err = ac.acm.DeleteCertificateWithContext(ctx, &acm.DeleteCertificateInput{
+		CertificateArn: aws.String(certificateArn)})
+	if err != nil {
+		return err
+	}
+
+	// the certificate is gone so failing to clean up the records is logged rather than failing the delete
+	err = ac.removeRecords(ctx, certificateArn, zones, res.Certificate.DomainValidationOptions)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("failed to remove validation records")
+	}
+
+	return nil
+}
+
+// waitUntilNotInUse polls the certificate until it is no longer in use by other resources, the wait is
+// bounded by the time remaining in the context, and when using InUseFail or InUseRetain by the max attempts
+func (ac *certificateApprover) waitUntilNotInUse(ctx context.Context, certificateArn string, policy InUsePolicy) (*acm.DescribeCertificateOutput, error) {
+	zerolog.Ctx(ctx).Info().Str("policy", string(policy)).Msg("Delete waiting for InUseBy of 0")
+
+	start := ac.clock.Now()
+
+	for i := 1; ; i++ {
 		res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
 			CertificateArn: aws.String(certificateArn),
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if len(res.Certificate.InUseBy) == 0 {
-			log.Info().Int("InUseBy", len(res.Certificate.InUseBy)).Msg("certificate InUseBy check done")
-			break
+			zerolog.Ctx(ctx).Info().Int("InUseBy", len(res.Certificate.InUseBy)).Msg("certificate InUseBy check done")
+			ac.recordDuration(metricDeleteWait, "Delete", start, nil)
+			ac.recordRetries("DeleteWait", i)
+			return res, nil
 		}
 
-		time.Sleep(deletionPollTime)
+		delay := ac.timing.pollDelay(ac.timing.deletionPollTime, i)
+
+		if (policy != InUseWait && i >= ac.timing.maxAttempts) || !ac.hasTimeFor(ctx, delay) {
+			zerolog.Ctx(ctx).Info().Int("InUseBy", len(res.Certificate.InUseBy)).Int("attempts", i).Msg("certificate InUseBy wait exhausted")
+			ac.recordDuration(metricDeleteWait, "Delete", start, ErrInUse)
+			ac.recordRetries("DeleteWait", i)
+			return res, nil
+		}
+
+		err = ac.clock.Sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
 	}
+}
 
-	log.Info().Str("certificateArn", certificateArn).Msg("deleting certificate")
+// removeRecords deletes the validation records of a deleted certificate, records which are still
+// referenced by another certificate are retained as ACM uses the same record for a domain across
+// certificates and regions in an account. If the other regions can't be checked the records are retained.
+func (ac *certificateApprover) removeRecords(ctx context.Context, certificateArn string, zones Zones, validations []*acm.DomainValidation) error {
+	provider := ac.dnsProvider(zones)
 
-	_, err := ac.acm.DeleteCertificateWithContext(ctx, &acm.DeleteCertificateInput{
-		CertificateArn: aws.String(certificateArn)})
+	grouped, err := groupRecordsByZone(ctx, provider, validations)
 	if err != nil {
 		return err
 	}
 
+	if len(grouped) == 0 {
+		return nil
+	}
+
+	referenced, err := ac.referencedRecordNames(ctx, certificateArn)
+	if err != nil {
+		return err
+	}
+
+	for _, zr := range grouped {
+		records := []dns.Record{}
+
+		for _, record := range zr.records {
+			if referenced[fqdn(record.Name)] {
+				zerolog.Ctx(ctx).Info().Str("record", fqdn(record.Name)).Msg("validation record is referenced by another certificate, skipping")
+				continue
+			}
+
+			records = append(records, record)
+		}
+
+		if len(records) == 0 {
+			continue
+		}
+
+		_, err = provider.Delete(ctx, zr.hostedZoneID, records)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// referencedRecordNames returns the names of the validation records used by certificates in every enabled
+// region of the account, other than the excluded certificate
+func (ac *certificateApprover) referencedRecordNames(ctx context.Context, excludeArn string) (map[string]bool, error) {
+	clients := []acmiface.ACMAPI{ac.acm}
+
+	if ac.regionalACM != nil {
+		regional, err := ac.regionalACM(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, regional...)
+	}
+
+	names := map[string]bool{}
+
+	for _, client := range clients {
+		err := addReferencedRecordNames(ctx, client, excludeArn, names)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+// addReferencedRecordNames adds the names of the validation records used by the certificates listed by the client
+func addReferencedRecordNames(ctx context.Context, client acmiface.ACMAPI, excludeArn string, names map[string]bool) error {
+	certificateArns := []string{}
+
+	err := client.ListCertificatesPagesWithContext(ctx, &acm.ListCertificatesInput{
+		Includes: &acm.Filters{KeyTypes: aws.StringSlice(keyTypes)},
+	}, func(page *acm.ListCertificatesOutput, lastPage bool) bool {
+		for _, summary := range page.CertificateSummaryList {
+			if aws.StringValue(summary.CertificateArn) == excludeArn {
+				continue
+			}
+
+			certificateArns = append(certificateArns, aws.StringValue(summary.CertificateArn))
+		}
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list certificates")
+	}
+
+	for _, certificateArn := range certificateArns {
+		res, err := client.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+			CertificateArn: aws.String(certificateArn),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+		}
+
+		for _, domainValidation := range res.Certificate.DomainValidationOptions {
+			if domainValidation.ResourceRecord != nil {
+				names[fqdn(aws.StringValue(domainValidation.ResourceRecord.Name))] = true
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1,16 +1,28 @@
-package approver
+package approver_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
 	"github.com/wolfeidau/serverless-acm-approver/mocks"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
 )
 
 func TestDelete(t *testing.T) {
@@ -24,12 +36,194 @@ func TestDelete(t *testing.T) {
 	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{InUseBy: []*string{}}}, nil)
 	acmapi.EXPECT().DeleteCertificateWithContext(gomock.Any(), &acm.DeleteCertificateInput{CertificateArn: aws.String("ghi789")}).Return(&acm.DeleteCertificateOutput{}, nil)
 
-	ca := certificateApprover{acm: acmapi}
+	ca := approver.NewWithClients(acmapi, nil)
 
-	err := ca.Delete(context.TODO(), "ghi789")
+	err := ca.Delete(context.TODO(), "ghi789", approver.Zones{}, approver.InUseFail)
 	assert.NoError(err)
 }
 
+func TestDelete_InUse(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    approver.InUsePolicy
+		describes int
+		wantErr   bool
+	}{
+		{name: "fail policy should return in use error after max attempts", policy: approver.InUseFail, describes: 3, wantErr: true},
+		{name: "wait policy should return in use error when out of time", policy: approver.InUseWait, describes: 4, wantErr: true},
+		{name: "retain policy should leave certificate after max attempts", policy: approver.InUseRetain, describes: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			acmapi := mocks.NewMockACMAPI(ctrl)
+
+			acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				InUseBy: aws.StringSlice([]string{"arn:aws:cloudfront::123456789012:distribution/A", "arn:aws:elasticloadbalancing:b"}),
+			}}, nil).Times(tt.describes)
+
+			clock := mocks.NewFakeClock(time.Now())
+
+			ca := approver.NewWithClients(acmapi, nil, approver.WithClock(clock), approver.WithMaxAttempts(3))
+
+			ctx, cancel := context.WithDeadline(context.TODO(), clock.Now().Add(2*time.Minute))
+			defer cancel()
+
+			err := ca.Delete(ctx, "ghi789", approver.Zones{}, tt.policy)
+			assert.Len(clock.Sleeps(), tt.describes-1)
+
+			if !tt.wantErr {
+				assert.NoError(err)
+				return
+			}
+
+			var inUseErr *approver.InUseError
+			assert.True(errors.As(err, &inUseErr))
+			assert.Equal([]string{"arn:aws:cloudfront::123456789012:distribution/A", "arn:aws:elasticloadbalancing:b"}, inUseErr.InUseBy)
+			assert.EqualError(err, "certificate ghi789 is still in use by 2 resources: arn:aws:cloudfront::123456789012:distribution/A, arn:aws:elasticloadbalancing:b")
+		})
+	}
+}
+
+func TestDelete_RemoveRecords(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			InUseBy:        []*string{},
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.www.t.co."), Type: aws.String("CNAME"), Value: aws.String("def")},
+				},
+			}}}, nil)
+	acmapi.EXPECT().DeleteCertificateWithContext(gomock.Any(), &acm.DeleteCertificateInput{CertificateArn: aws.String("ghi789")}).Return(&acm.DeleteCertificateOutput{}, nil)
+
+	// another certificate still uses the record for t.co
+	acmapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *acm.ListCertificatesInput, fn func(*acm.ListCertificatesOutput, bool) bool, _ ...interface{}) error {
+			fn(&acm.ListCertificatesOutput{CertificateSummaryList: []*acm.CertificateSummary{{CertificateArn: aws.String("jkl012")}}}, true)
+			return nil
+		})
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("jkl012")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("jkl012"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+			}}}, nil)
+
+	recordSet := &route53.ResourceRecordSet{
+		Name:            aws.String("_b.www.t.co."),
+		Type:            aws.String("CNAME"),
+		TTL:             aws.Int64(300),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("def")}},
+	}
+
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String("ZONE1"),
+		StartRecordName: aws.String("_b.www.t.co."),
+		StartRecordType: aws.String("CNAME"),
+		MaxItems:        aws.String("1"),
+	}).Return(&route53.ListResourceRecordSetsOutput{ResourceRecordSets: []*route53.ResourceRecordSet{recordSet}}, nil)
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String("ZONE1"),
+		ChangeBatch: &route53.ChangeBatch{Changes: []*route53.Change{
+			{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: recordSet},
+		}},
+	}).Return(&route53.ChangeResourceRecordSetsOutput{}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Delete(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"}, approver.InUseFail)
+	assert.NoError(err)
+}
+
+func TestDelete_RemoveRecordsOtherRegions(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	regionalapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+			}}}, nil)
+	acmapi.EXPECT().DeleteCertificateWithContext(gomock.Any(), gomock.Any()).Return(&acm.DeleteCertificateOutput{}, nil)
+	acmapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	// a certificate in another region still uses the record so it isn't deleted
+	regionalapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *acm.ListCertificatesInput, fn func(*acm.ListCertificatesOutput, bool) bool, _ ...interface{}) error {
+			fn(&acm.ListCertificatesOutput{CertificateSummaryList: []*acm.CertificateSummary{{CertificateArn: aws.String("jkl012")}}}, true)
+			return nil
+		})
+	regionalapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("jkl012")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("jkl012"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+			}}}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithRegionalClients(func(context.Context) ([]acmiface.ACMAPI, error) {
+		return []acmiface.ACMAPI{regionalapi}, nil
+	}))
+
+	err := ca.Delete(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"}, approver.InUseFail)
+	assert.NoError(err)
+}
+
+func TestDelete_RemoveRecordsRegionsUnavailable(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+			}}}, nil)
+	acmapi.EXPECT().DeleteCertificateWithContext(gomock.Any(), gomock.Any()).Return(&acm.DeleteCertificateOutput{}, nil)
+
+	// the other regions can't be checked so the records are retained
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithRegionalClients(func(context.Context) ([]acmiface.ACMAPI, error) {
+		return nil, errors.New("failed to list the enabled regions")
+	}))
+
+	err := ca.Delete(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"}, approver.InUseFail)
+	assert.NoError(err)
+}
+
+var issuedCertificate = &acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+	CertificateArn: aws.String("ghi789"),
+	Status:         aws.String(acm.CertificateStatusIssued),
+}}
+
 func TestApprove(t *testing.T) {
 	assert := require.New(t)
 
@@ -48,15 +242,153 @@ func TestApprove(t *testing.T) {
 				},
 			}}}, nil)
 
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(
+		&route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/C1")}}, nil)
+	route53api.EXPECT().WaitUntilResourceRecordSetsChangedWithContext(gomock.Any(), &route53.GetChangeInput{Id: aws.String("/change/C1")}, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(issuedCertificate, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "a.1.t.co"})
+	assert.NoError(err)
+}
+
+func TestApprove_PendingRecords(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	gomock.InOrder(
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn:          aws.String("ghi789"),
+				DomainValidationOptions: []*acm.DomainValidation{{DomainName: aws.String("a.1.t.co")}},
+			}}, nil).Times(2),
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				DomainValidationOptions: []*acm.DomainValidation{
+					{
+						DomainName:     aws.String("a.1.t.co"),
+						ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.1.t.co"), Type: aws.String("CNAME"), Value: aws.String("abc")},
+					},
+				}}}, nil),
+	)
+
 	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ChangeResourceRecordSetsOutput{}, nil)
-	acmapi.EXPECT().WaitUntilCertificateValidatedWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}, gomock.Any(), gomock.Any()).Return(nil)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(issuedCertificate, nil)
+
+	clock := mocks.NewFakeClock(time.Now())
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithClock(clock))
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+	assert.Equal([]time.Duration{5 * time.Second, 5 * time.Second}, clock.Sleeps())
+}
+
+func TestApprove_PendingSANRecords(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	recordA := &acm.ResourceRecord{Name: aws.String("_a.1.t.co"), Type: aws.String("CNAME"), Value: aws.String("abc")}
+	recordB := &acm.ResourceRecord{Name: aws.String("_b.1.t.co"), Type: aws.String("CNAME"), Value: aws.String("def")}
+
+	// the record for the subject alternative name is generated after the one for the domain name
+	gomock.InOrder(
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				DomainValidationOptions: []*acm.DomainValidation{
+					{DomainName: aws.String("a.1.t.co"), ResourceRecord: recordA},
+					{DomainName: aws.String("b.1.t.co")},
+				}}}, nil),
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				DomainValidationOptions: []*acm.DomainValidation{
+					{DomainName: aws.String("a.1.t.co"), ResourceRecord: recordA},
+					{DomainName: aws.String("b.1.t.co"), ResourceRecord: recordB},
+				}}}, nil),
+	)
 
-	ca := certificateApprover{acm: acmapi, route53: route53api}
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+			assert.Len(input.ChangeBatch.Changes, 2)
+			return &route53.ChangeResourceRecordSetsOutput{}, nil
+		})
 
-	err := ca.Approve(context.TODO(), "ghi789", "a.1.t.co")
+	clock := mocks.NewFakeClock(time.Now())
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithClock(clock))
+
+	err := ca.Publish(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
 	assert.NoError(err)
 }
 
+func TestApprove_RecordsNotGenerated(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn:          aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{{DomainName: aws.String("a.1.t.co")}},
+		}}, nil).Times(3)
+
+	clock := mocks.NewFakeClock(time.Now())
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithClock(clock), approver.WithMaxAttempts(3))
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.True(errors.Is(err, approver.ErrRecordsNotGenerated))
+	assert.True(errors.Is(err, approver.ErrTimeout))
+	assert.Contains(err.Error(), "missing records for a.1.t.co after 3 attempts")
+}
+
+func TestApprove_ChangeNotInSync(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.1.t.co"), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+			}}}, nil)
+
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(
+		&route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/C1")}}, nil)
+	route53api.EXPECT().WaitUntilResourceRecordSetsChangedWithContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		awserr.New(request.WaiterResourceNotReadyErrorCode, "exceeded wait attempts", nil))
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.True(errors.Is(err, approver.ErrChangeNotInSync))
+}
+
 func TestCreate(t *testing.T) {
 	assert := require.New(t)
 
@@ -73,9 +405,1020 @@ func TestCreate(t *testing.T) {
 		ValidationMethod:        aws.String("DNS"),
 	}).Return(&acm.RequestCertificateOutput{CertificateArn: aws.String("ghi789")}, nil)
 
-	ca := certificateApprover{acm: acmapi, route53: route53api}
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	certificateArn, err := ca.Request(context.TODO(), "abc123", "a.1.t.co", []string{""}, nil)
+	assert.NoError(err)
+	assert.Equal("ghi789", certificateArn)
+}
+
+func TestCreate_Tags(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().RequestCertificateWithContext(gomock.Any(), &acm.RequestCertificateInput{
+		DomainName:              aws.String("a.1.t.co"),
+		IdempotencyToken:        aws.String("5c69bb695cc29b93d655e1a4bb5656cd"),
+		SubjectAlternativeNames: []*string{aws.String("")},
+		ValidationMethod:        aws.String("DNS"),
+		Tags: []*acm.Tag{
+			{Key: aws.String("Team"), Value: aws.String("platform")},
+			{Key: aws.String(approver.TagStackID), Value: aws.String("stack-1")},
+		},
+	}).Return(&acm.RequestCertificateOutput{CertificateArn: aws.String("ghi789")}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
 
-	certificateArn, err := ca.Request(context.TODO(), "abc123", "a.1.t.co", []string{""})
+	certificateArn, err := ca.Request(context.TODO(), "abc123", "a.1.t.co", []string{""}, map[string]string{
+		approver.TagStackID: "stack-1",
+		"Team":              "platform",
+	})
 	assert.NoError(err)
 	assert.Equal("ghi789", certificateArn)
 }
+
+func TestFindIssued(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *acm.ListCertificatesInput, fn func(*acm.ListCertificatesOutput, bool) bool, opts ...request.Option) error {
+			assert.Equal([]string{acm.CertificateStatusIssued}, aws.StringValueSlice(input.CertificateStatuses))
+			fn(&acm.ListCertificatesOutput{
+				CertificateSummaryList: []*acm.CertificateSummary{
+					{CertificateArn: aws.String("other"), DomainName: aws.String("b.1.t.co")},
+					{CertificateArn: aws.String("extra-san"), DomainName: aws.String("a.1.t.co")},
+					{CertificateArn: aws.String("match"), DomainName: aws.String("a.1.t.co")},
+				},
+			}, true)
+			return nil
+		})
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("extra-san")}).Return(&acm.DescribeCertificateOutput{
+		Certificate: &acm.CertificateDetail{
+			DomainName:              aws.String("a.1.t.co"),
+			SubjectAlternativeNames: aws.StringSlice([]string{"a.1.t.co", "*.a.1.t.co", "c.1.t.co"}),
+			Status:                  aws.String(acm.CertificateStatusIssued),
+			Type:                    aws.String(acm.CertificateTypeAmazonIssued),
+		},
+	}, nil)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("match")}).Return(&acm.DescribeCertificateOutput{
+		Certificate: &acm.CertificateDetail{
+			DomainName:              aws.String("a.1.t.co"),
+			SubjectAlternativeNames: aws.StringSlice([]string{"a.1.t.co", "*.a.1.t.co"}),
+			Status:                  aws.String(acm.CertificateStatusIssued),
+			Type:                    aws.String(acm.CertificateTypeAmazonIssued),
+		},
+	}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	certificateArn, err := ca.FindIssued(context.TODO(), "a.1.t.co", []string{"*.A.1.t.co"})
+	assert.NoError(err)
+	assert.Equal("match", certificateArn)
+}
+
+func TestTag(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	gomock.InOrder(
+		acmapi.EXPECT().RemoveTagsFromCertificateWithContext(gomock.Any(), &acm.RemoveTagsFromCertificateInput{
+			CertificateArn: aws.String("ghi789"),
+			Tags:           []*acm.Tag{{Key: aws.String("Owner")}},
+		}).Return(&acm.RemoveTagsFromCertificateOutput{}, nil),
+		acmapi.EXPECT().AddTagsToCertificateWithContext(gomock.Any(), &acm.AddTagsToCertificateInput{
+			CertificateArn: aws.String("ghi789"),
+			Tags:           []*acm.Tag{{Key: aws.String("Team"), Value: aws.String("platform")}},
+		}).Return(&acm.AddTagsToCertificateOutput{}, nil),
+	)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Tag(context.TODO(), "ghi789", map[string]string{"Team": "platform"}, []string{"Owner"})
+	assert.NoError(err)
+}
+
+func TestStatus(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).
+		Return(&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			DomainName:         aws.String("t.1.co"),
+			Status:             aws.String(acm.CertificateStatusIssued),
+			Type:               aws.String(acm.CertificateTypeAmazonIssued),
+			RenewalEligibility: aws.String(acm.RenewalEligibilityIneligible),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{DomainName: aws.String("t.1.co"), ValidationStatus: aws.String(acm.DomainStatusSuccess)},
+			},
+			RenewalSummary: &acm.RenewalSummary{RenewalStatus: aws.String(acm.RenewalStatusPendingAutoRenewal)},
+		}}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	status, err := ca.Status(context.TODO(), "ghi789")
+	assert.NoError(err)
+	assert.False(status.Eligible())
+	assert.Equal(acm.CertificateStatusIssued, status.Status)
+	assert.Equal(acm.RenewalStatusPendingAutoRenewal, status.RenewalStatus)
+	assert.Equal([]approver.DomainStatus{{DomainName: "t.1.co", ValidationStatus: acm.DomainStatusSuccess}}, status.Domains)
+}
+
+func TestRepair(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			Status:         aws.String(acm.CertificateStatusIssued),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc.acm-validations.aws.")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"), Value: aws.String("def.acm-validations.aws.")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_c.t.co."), Type: aws.String("CNAME"), Value: aws.String("ghi.acm-validations.aws.")}},
+			}}}, nil)
+
+	listRecord := func(name string, recordSets ...*route53.ResourceRecordSet) {
+		route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), &route53.ListResourceRecordSetsInput{
+			HostedZoneId:    aws.String("ZONE1"),
+			StartRecordName: aws.String(name),
+			StartRecordType: aws.String("CNAME"),
+			MaxItems:        aws.String("1"),
+		}).Return(&route53.ListResourceRecordSetsOutput{ResourceRecordSets: recordSets}, nil)
+	}
+
+	// _a is in sync, _b has been changed and _c is missing so the next record in the zone is returned
+	listRecord("_a.t.co.", &route53.ResourceRecordSet{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("abc.acm-validations.aws")}}})
+	listRecord("_b.t.co.", &route53.ResourceRecordSet{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("old.acm-validations.aws.")}}})
+	listRecord("_c.t.co.", &route53.ResourceRecordSet{Name: aws.String("www.t.co."), Type: aws.String("CNAME"),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("t.co.")}}})
+
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+			assert.Len(input.ChangeBatch.Changes, 2)
+			assert.Equal("_b.t.co.", aws.StringValue(input.ChangeBatch.Changes[0].ResourceRecordSet.Name))
+			assert.Equal("_c.t.co.", aws.StringValue(input.ChangeBatch.Changes[1].ResourceRecordSet.Name))
+			return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/C1")}}, nil
+		})
+	route53api.EXPECT().WaitUntilResourceRecordSetsChangedWithContext(gomock.Any(), &route53.GetChangeInput{Id: aws.String("/change/C1")}, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	repairs, err := ca.Repair(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+	assert.Equal([]approver.RecordRepair{
+		{HostedZoneID: "ZONE1", Name: "_b.t.co.", Type: "CNAME", Value: "def.acm-validations.aws.", Previous: []string{"old.acm-validations.aws."}},
+		{HostedZoneID: "ZONE1", Name: "_c.t.co.", Type: "CNAME", Value: "ghi.acm-validations.aws."},
+	}, repairs)
+	assert.True(repairs[1].Missing())
+}
+
+func TestListManaged(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *acm.ListCertificatesInput, fn func(*acm.ListCertificatesOutput, bool) bool, _ ...interface{}) error {
+			fn(&acm.ListCertificatesOutput{CertificateSummaryList: []*acm.CertificateSummary{
+				{CertificateArn: aws.String("ghi789")}, {CertificateArn: aws.String("jkl012")},
+			}}, true)
+			return nil
+		})
+	acmapi.EXPECT().ListTagsForCertificateWithContext(gomock.Any(), &acm.ListTagsForCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.ListTagsForCertificateOutput{Tags: []*acm.Tag{{Key: aws.String(approver.TagStackID), Value: aws.String("stack")}}}, nil)
+	acmapi.EXPECT().ListTagsForCertificateWithContext(gomock.Any(), &acm.ListTagsForCertificateInput{CertificateArn: aws.String("jkl012")}).Return(
+		&acm.ListTagsForCertificateOutput{}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	certificateArns, err := ca.ListManaged(context.TODO())
+	assert.NoError(err)
+	assert.Equal([]string{"ghi789"}, certificateArns)
+}
+
+func TestPlanRequest(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	route53api.EXPECT().GetHostedZoneWithContext(gomock.Any(), &route53.GetHostedZoneInput{Id: aws.String("ZONE1")}).Return(&route53.GetHostedZoneOutput{
+		HostedZone: &route53.HostedZone{Id: aws.String("/hostedzone/ZONE1"), Name: aws.String("t.co."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)}},
+	}, nil)
+
+	// neither name has CAA records
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ListResourceRecordSetsOutput{}, nil).AnyTimes()
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	plan, err := ca.PlanRequest(context.TODO(), "t.co", []string{"*.t.co"}, approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+	assert.Equal(`acm RequestCertificate *.t.co, t.co
+route53 ChangeResourceRecordSets ZONE1 (1 UPSERT)
+UPSERT CNAME (generated by ACM for t.co.) in zone ZONE1`, plan.String())
+}
+
+func TestPlanApprove(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			Status:         aws.String(acm.CertificateStatusPendingValidation),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc.")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"), Value: aws.String("def.")}},
+			}}}, nil)
+
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []*route53.ResourceRecordSet{{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("abc.")}}}}}, nil)
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []*route53.ResourceRecordSet{{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("old.")}}}}}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	plan, err := ca.PlanApprove(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+	assert.Equal(`route53 ChangeResourceRecordSets ZONE1 (1 UPSERT)
+SKIP CNAME _a.t.co. in zone ZONE1 -> abc. (already in the hosted zone)
+UPSERT CNAME _b.t.co. in zone ZONE1 -> def. replacing old.
+note: certificate is PENDING_VALIDATION, approve would wait for it to be issued`, plan.String())
+}
+
+func TestPlanDelete(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			InUseBy:        aws.StringSlice([]string{"arn:elb"}),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc.")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"), Value: aws.String("def.")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_c.t.co."), Type: aws.String("CNAME"), Value: aws.String("ghi.")}},
+			}}}, nil)
+
+	// the certificate being deleted is excluded, jkl012 still uses the record for _a
+	acmapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *acm.ListCertificatesInput, fn func(*acm.ListCertificatesOutput, bool) bool, _ ...interface{}) error {
+			fn(&acm.ListCertificatesOutput{CertificateSummaryList: []*acm.CertificateSummary{
+				{CertificateArn: aws.String("ghi789")}, {CertificateArn: aws.String("jkl012")},
+			}}, true)
+			return nil
+		})
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("jkl012")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc.")}},
+			}}}, nil)
+
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []*route53.ResourceRecordSet{{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("def.")}}}}}, nil)
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ListResourceRecordSetsOutput{}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	plan, err := ca.PlanDelete(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"}, approver.InUseRetain)
+	assert.NoError(err)
+	assert.Equal(`acm DeleteCertificate ghi789
+route53 ChangeResourceRecordSets ZONE1 (1 DELETE)
+SKIP CNAME _a.t.co. in zone ZONE1 -> abc. (referenced by another certificate)
+DELETE CNAME _b.t.co. in zone ZONE1 -> def.
+SKIP CNAME _c.t.co. in zone ZONE1 -> ghi. (not found in the hosted zone)
+note: certificate is in use by arn:elb, delete would wait for it to be released then retain it`, plan.String())
+}
+
+func TestApprove_DiscoverHostedZone(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.www.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"), Value: aws.String("def")},
+				},
+			}}}, nil)
+
+	// the ACM token label is skipped and each zone name is only listed once
+	route53api.EXPECT().ListHostedZonesByNameWithContext(gomock.Any(), &route53.ListHostedZonesByNameInput{DNSName: aws.String("www.t.co.")}).Return(
+		&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{
+			{Id: aws.String("/hostedzone/ZPRIVATE"), Name: aws.String("www.t.co."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)}},
+		}}, nil)
+	route53api.EXPECT().ListHostedZonesByNameWithContext(gomock.Any(), &route53.ListHostedZonesByNameInput{DNSName: aws.String("t.co.")}).Return(
+		&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{
+			{Id: aws.String("/hostedzone/ZPUBLIC"), Name: aws.String("t.co."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)}},
+		}}, nil)
+
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...interface{}) (*route53.ChangeResourceRecordSetsOutput, error) {
+			assert.Equal("ZPUBLIC", aws.StringValue(input.HostedZoneId))
+			return &route53.ChangeResourceRecordSetsOutput{}, nil
+		})
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(issuedCertificate, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{})
+	assert.NoError(err)
+}
+
+func TestApprove_AmbiguousHostedZone(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+			}}}, nil)
+
+	route53api.EXPECT().ListHostedZonesByNameWithContext(gomock.Any(), &route53.ListHostedZonesByNameInput{DNSName: aws.String("t.co.")}).Return(
+		&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{
+			{Id: aws.String("/hostedzone/ZONE1"), Name: aws.String("t.co.")},
+			{Id: aws.String("/hostedzone/ZONE2"), Name: aws.String("t.co.")},
+		}}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{})
+	assert.EqualError(err, "found 2 public hosted zones named t.co. for record _a.t.co., HostedZoneId is required to select one")
+}
+
+func TestApprove_DomainHostedZones(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.www.t.net."), Type: aws.String("CNAME"), Value: aws.String("def")},
+				},
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_c.t.org."), Type: aws.String("CNAME"), Value: aws.String("ghi")},
+				},
+			}}}, nil)
+
+	zoneIDs := []string{}
+
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...interface{}) (*route53.ChangeResourceRecordSetsOutput, error) {
+			zoneIDs = append(zoneIDs, aws.StringValue(input.HostedZoneId))
+			return &route53.ChangeResourceRecordSetsOutput{}, nil
+		}).Times(3)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(issuedCertificate, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{
+		HostedZoneID: "ZDEFAULT",
+		Domains:      map[string]string{"t.co": "ZCO", "t.net.": "/hostedzone/ZNET"},
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"ZCO", "ZNET", "ZDEFAULT"}, zoneIDs)
+}
+
+func TestApprove_PreferredHostedZone(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.www.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.net."), Type: aws.String("CNAME"), Value: aws.String("def")},
+				},
+			}}}, nil)
+
+	// the preferred zone is looked up once, names outside it are discovered
+	route53api.EXPECT().GetHostedZoneWithContext(gomock.Any(), &route53.GetHostedZoneInput{Id: aws.String("ZPREFERRED")}).Return(
+		&route53.GetHostedZoneOutput{HostedZone: &route53.HostedZone{Id: aws.String("/hostedzone/ZPREFERRED"), Name: aws.String("t.co.")}}, nil)
+	route53api.EXPECT().ListHostedZonesByNameWithContext(gomock.Any(), &route53.ListHostedZonesByNameInput{DNSName: aws.String("t.net.")}).Return(
+		&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{{Id: aws.String("/hostedzone/ZNET"), Name: aws.String("t.net.")}}}, nil)
+
+	zoneIDs := []string{}
+
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...interface{}) (*route53.ChangeResourceRecordSetsOutput, error) {
+			zoneIDs = append(zoneIDs, aws.StringValue(input.HostedZoneId))
+			return &route53.ChangeResourceRecordSetsOutput{}, nil
+		}).Times(2)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(issuedCertificate, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{PreferredHostedZoneID: "/hostedzone/ZPREFERRED"})
+	assert.NoError(err)
+	assert.Equal([]string{"ZPREFERRED", "ZNET"}, zoneIDs)
+}
+
+func TestZonesTag(t *testing.T) {
+	assert := require.New(t)
+
+	zones := approver.Zones{HostedZoneID: "ZDEFAULT", Domains: map[string]string{"t.net": "ZNET", "*.t.co": "/hostedzone/ZCO"}}
+
+	value, ok := approver.ZonesTag(zones)
+	assert.True(ok)
+	assert.Equal("ZDEFAULT t.co=/hostedzone/ZCO t.net=ZNET", value)
+
+	assert.Equal(approver.Zones{HostedZoneID: "ZDEFAULT", Domains: map[string]string{"t.net": "ZNET", "t.co": "/hostedzone/ZCO"}}, approver.ParseZonesTag(value))
+
+	_, ok = approver.ZonesTag(approver.Zones{HostedZoneID: strings.Repeat("Z", 257)})
+	assert.False(ok)
+}
+
+func TestApprove_DeduplicateRecords(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					DomainName:     aws.String("t.co"),
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+				{
+					DomainName:     aws.String("*.t.co"),
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+				{
+					DomainName:     aws.String("www.t.co"),
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.www.t.co."), Type: aws.String("CNAME"), Value: aws.String("def")},
+				},
+			}}}, nil)
+
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...interface{}) (*route53.ChangeResourceRecordSetsOutput, error) {
+			assert.Len(input.ChangeBatch.Changes, 2)
+			return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/C1")}}, nil
+		})
+	route53api.EXPECT().WaitUntilResourceRecordSetsChangedWithContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(issuedCertificate, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+}
+
+func TestApprove_DNSProvider(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+	provider := mocks.NewMockProvider(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.io."), Type: aws.String("CNAME"), Value: aws.String("def")}},
+			}}}, nil)
+
+	// records are published by the provider so route53 isn't called
+	provider.EXPECT().Zone(gomock.Any(), "_a.t.co.").Return("t.co", nil)
+	provider.EXPECT().Zone(gomock.Any(), "_b.t.io.").Return("t.io", nil)
+	provider.EXPECT().Upsert(gomock.Any(), "t.co", []dns.Record{{Name: "_a.t.co.", Type: "CNAME", Value: "abc"}}).Return(
+		[]dns.Change{{ZoneID: "t.co", ID: "C1"}}, nil)
+	provider.EXPECT().Upsert(gomock.Any(), "t.io", []dns.Record{{Name: "_b.t.io.", Type: "CNAME", Value: "def"}}).Return(nil, nil)
+	provider.EXPECT().Wait(gomock.Any(), []dns.Change{{ZoneID: "t.co", ID: "C1"}}).Return(nil)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(issuedCertificate, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithDNSProvider(provider))
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+}
+
+func TestApprove_Route53Provider(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+	providerRoute53 := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+			}}}, nil)
+
+	// the provider uses its own client and options rather than those of the approver
+	providerRoute53.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *route53.ChangeResourceRecordSetsInput, _ ...interface{}) (*route53.ChangeResourceRecordSetsOutput, error) {
+			assert.Equal("ZONE2", aws.StringValue(input.HostedZoneId))
+			assert.Equal(int64(300), aws.Int64Value(input.ChangeBatch.Changes[0].ResourceRecordSet.TTL))
+			return &route53.ChangeResourceRecordSetsOutput{}, nil
+		})
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(issuedCertificate, nil)
+
+	provider := approver.NewRoute53Provider(providerRoute53, approver.Zones{HostedZoneID: "ZONE2"}, approver.WithRecordTTL(300))
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithDNSProvider(provider))
+
+	err := ca.Approve(context.TODO(), "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+	assert.NoError(err)
+}
+
+func TestPlanRequest_DNSProvider(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	// the provider describes the calls it would make
+	provider.EXPECT().Zone(gomock.Any(), gomock.Any()).Return("t.co", nil).Times(2)
+	provider.EXPECT().Operation(approver.RecordUpsert).Return(dns.Operation{Service: "dns", Name: "Upsert"})
+
+	ca := approver.NewWithClients(mocks.NewMockACMAPI(ctrl), mocks.NewMockRoute53API(ctrl), approver.WithDNSProvider(provider))
+
+	plan, err := ca.PlanRequest(context.TODO(), "t.co", []string{}, approver.Zones{})
+	assert.NoError(err)
+	assert.Equal(`acm RequestCertificate t.co
+dns Upsert t.co (1 UPSERT)
+UPSERT CNAME (generated by ACM for t.co.) in zone t.co`, plan.String())
+}
+
+func TestDelete_DNSProvider(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+	provider := mocks.NewMockProvider(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("ghi789")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_b.t.co."), Type: aws.String("CNAME"), Value: aws.String("def")}},
+			}}}, nil)
+	acmapi.EXPECT().DeleteCertificateWithContext(gomock.Any(), &acm.DeleteCertificateInput{CertificateArn: aws.String("ghi789")}).Return(&acm.DeleteCertificateOutput{}, nil)
+
+	// another certificate still uses the record for _a
+	acmapi.EXPECT().ListCertificatesPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *acm.ListCertificatesInput, fn func(*acm.ListCertificatesOutput, bool) bool, _ ...interface{}) error {
+			fn(&acm.ListCertificatesOutput{CertificateSummaryList: []*acm.CertificateSummary{{CertificateArn: aws.String("jkl012")}}}, true)
+			return nil
+		})
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), &acm.DescribeCertificateInput{CertificateArn: aws.String("jkl012")}).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			DomainValidationOptions: []*acm.DomainValidation{
+				{ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.t.co."), Type: aws.String("CNAME"), Value: aws.String("abc")}},
+			}}}, nil)
+
+	provider.EXPECT().Zone(gomock.Any(), gomock.Any()).Return("t.co", nil).Times(2)
+	provider.EXPECT().Delete(gomock.Any(), "t.co", []dns.Record{{Name: "_b.t.co.", Type: "CNAME", Value: "def"}}).Return(nil, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithDNSProvider(provider))
+
+	err := ca.Delete(context.TODO(), "ghi789", approver.Zones{}, approver.InUseFail)
+	assert.NoError(err)
+}
+
+func TestBatchChanges(t *testing.T) {
+	assert := require.New(t)
+
+	changes := []*route53.Change{}
+
+	for i := 0; i < 1001; i++ {
+		changes = append(changes, &route53.Change{
+			Action: aws.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("_abc.acm-validations.aws.")}},
+			},
+		})
+	}
+
+	batches := approver.BatchChanges(changes)
+	assert.Len(batches, 3)
+	assert.Len(batches[0], 500)
+	assert.Len(batches[1], 500)
+	assert.Len(batches[2], 1)
+}
+
+func TestPollDelay(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(5*time.Second, approver.PollDelay(nil, 5*time.Second, 4))
+
+	backoff := []approver.Option{approver.WithBackoff(time.Minute)}
+
+	for attempt, want := range map[int]time.Duration{1: 5 * time.Second, 2: 10 * time.Second, 3: 20 * time.Second, 10: time.Minute} {
+		d := approver.PollDelay(backoff, 5*time.Second, attempt)
+		assert.True(d >= want/2 && d <= want, "attempt %d delay %s should be between %s and %s", attempt, d, want/2, want)
+	}
+}
+
+func TestApprove_ValidationTimeout(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			DomainValidationOptions: []*acm.DomainValidation{
+				{
+					ResourceRecord: &acm.ResourceRecord{Name: aws.String("_a.1.t.co"), Type: aws.String("CNAME"), Value: aws.String("abc")},
+				},
+			}}}, nil)
+	route53api.EXPECT().ChangeResourceRecordSetsWithContext(gomock.Any(), gomock.Any()).Return(&route53.ChangeResourceRecordSetsOutput{}, nil)
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ *acm.DescribeCertificateInput, _ ...request.Option) (*acm.DescribeCertificateOutput, error) {
+			<-ctx.Done()
+			return nil, awserr.New(request.CanceledErrorCode, "request context canceled", ctx.Err())
+		})
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+
+	err := ca.Approve(ctx, "ghi789", approver.Zones{HostedZoneID: "ZONE1"})
+
+	var timeoutErr *approver.TimeoutError
+	assert.True(errors.As(err, &timeoutErr))
+	assert.Equal("waiting for certificate validation", timeoutErr.Phase)
+}
+
+func TestWaitForValidation_Failed(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	gomock.InOrder(
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				Status:         aws.String(acm.CertificateStatusPendingValidation),
+			}}, nil),
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				Status:         aws.String(acm.CertificateStatusFailed),
+				FailureReason:  aws.String(acm.FailureReasonCaaError),
+				DomainValidationOptions: []*acm.DomainValidation{
+					{DomainName: aws.String("a.1.t.co"), ValidationStatus: aws.String(acm.DomainStatusFailed)},
+					{DomainName: aws.String("b.1.t.co"), ValidationStatus: aws.String(acm.DomainStatusSuccess)},
+				},
+			}}, nil),
+	)
+
+	clock := mocks.NewFakeClock(time.Now())
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithClock(clock))
+
+	err := ca.WaitForValidation(context.TODO(), "ghi789")
+
+	var validationErr *approver.ValidationError
+	assert.True(errors.As(err, &validationErr))
+	assert.Equal(acm.FailureReasonCaaError, validationErr.FailureReason)
+	assert.Equal([]approver.DomainStatus{
+		{DomainName: "a.1.t.co", ValidationStatus: acm.DomainStatusFailed},
+		{DomainName: "b.1.t.co", ValidationStatus: acm.DomainStatusSuccess},
+	}, validationErr.Domains)
+	assert.Equal([]time.Duration{30 * time.Second}, clock.Sleeps())
+}
+
+func TestWaitForValidation_Pending(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+		&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+			CertificateArn: aws.String("ghi789"),
+			Status:         aws.String(acm.CertificateStatusPendingValidation),
+		}}, nil).Times(3)
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithClock(mocks.NewFakeClock(time.Now())), approver.WithMaxAttempts(3))
+
+	err := ca.WaitForValidation(context.TODO(), "ghi789")
+	assert.True(errors.Is(err, approver.ErrValidationPending))
+}
+
+func TestCAAAllowsAmazon(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		wildcard bool
+		want     bool
+	}{
+		{name: "amazon issuer should allow", values: []string{`0 issue "amazon.com"`}, want: true},
+		{name: "other issuer should deny", values: []string{`0 issue "letsencrypt.org"`}, want: false},
+		{name: "issuer with parameters should allow", values: []string{`0 issue "amazontrust.com; account=1"`}, want: true},
+		{name: "empty issuer should deny", values: []string{`0 issue ";"`}, want: false},
+		{name: "only iodef should allow", values: []string{`0 iodef "mailto:security@t.co"`}, want: true},
+		{name: "issuewild should not restrict names", values: []string{`0 issuewild "letsencrypt.org"`}, want: true},
+		{name: "issuewild should restrict wildcards", values: []string{`0 issue "amazon.com"`, `0 issuewild "letsencrypt.org"`}, wildcard: true, want: false},
+		{name: "issue should apply to wildcards without issuewild", values: []string{`0 issue "awstrust.com"`}, wildcard: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, approver.CAAAllowsAmazon(tt.values, tt.wildcard))
+		})
+	}
+}
+
+func TestPreflight_CAA(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	route53api.EXPECT().GetHostedZoneWithContext(gomock.Any(), &route53.GetHostedZoneInput{Id: aws.String("ZONE1")}).Return(&route53.GetHostedZoneOutput{
+		HostedZone: &route53.HostedZone{Id: aws.String("/hostedzone/ZONE1"), Name: aws.String("1.t.co."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)}},
+	}, nil)
+
+	// www.a.1.t.co has no CAA records so the check climbs to a.1.t.co
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String("ZONE1"),
+		StartRecordName: aws.String("www.a.1.t.co."),
+		StartRecordType: aws.String(route53.RRTypeCaa),
+		MaxItems:        aws.String("1"),
+	}).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []*route53.ResourceRecordSet{
+			{Name: aws.String("x.a.1.t.co."), Type: aws.String(route53.RRTypeCname)},
+		},
+	}, nil)
+	route53api.EXPECT().ListResourceRecordSetsWithContext(gomock.Any(), &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String("ZONE1"),
+		StartRecordName: aws.String("a.1.t.co."),
+		StartRecordType: aws.String(route53.RRTypeCaa),
+		MaxItems:        aws.String("1"),
+	}).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []*route53.ResourceRecordSet{
+			{
+				Name:            aws.String("a.1.t.co."),
+				Type:            aws.String(route53.RRTypeCaa),
+				ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(`0 issue "letsencrypt.org"`)}},
+			},
+		},
+	}, nil)
+
+	ca := approver.NewWithClients(acmapi, route53api)
+
+	err := ca.Preflight(context.TODO(), "www.a.1.t.co", []string{}, approver.Zones{HostedZoneID: "ZONE1"})
+	assert.EqualError(err, `CAA records on a.1.t.co. don't allow ACM to issue a certificate for www.a.1.t.co, one of amazon.com, amazontrust.com, awstrust.com, amazonaws.com must be allowed: 0 issue "letsencrypt.org"`)
+}
+
+func TestPreflight_HostedZone(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    *route53.HostedZone
+		wantErr string
+	}{
+		{
+			name:    "private hosted zone should return error",
+			zone:    &route53.HostedZone{Id: aws.String("/hostedzone/ZONE1"), Name: aws.String("1.t.co."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)}},
+			wantErr: "hosted zone ZONE1 (1.t.co.) is private, ACM can't validate *.a.1.t.co using records in a private hosted zone",
+		},
+		{
+			name:    "name outside hosted zone should return error",
+			zone:    &route53.HostedZone{Id: aws.String("/hostedzone/ZONE1"), Name: aws.String("2.t.co."), Config: &route53.HostedZoneConfig{}},
+			wantErr: "*.a.1.t.co is not within hosted zone ZONE1 (2.t.co.)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			acmapi := mocks.NewMockACMAPI(ctrl)
+			route53api := mocks.NewMockRoute53API(ctrl)
+
+			route53api.EXPECT().GetHostedZoneWithContext(gomock.Any(), gomock.Any()).Return(&route53.GetHostedZoneOutput{HostedZone: tt.zone}, nil)
+
+			ca := approver.NewWithClients(acmapi, route53api)
+
+			err := ca.Preflight(context.TODO(), "*.a.1.t.co", nil, approver.Zones{HostedZoneID: "ZONE1"})
+			assert.EqualError(err, tt.wantErr)
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class error
+	}{
+		{name: "throttling should be ErrThrottled", err: awserr.New("ThrottlingException", "Rate exceeded", nil), class: approver.ErrThrottled},
+		{name: "invalid change batch should be ErrInvalidInput", err: awserr.New(route53.ErrCodeInvalidChangeBatch, "bad record", nil), class: approver.ErrInvalidInput},
+		{name: "resource in use should be ErrInUse", err: awserr.New(acm.ErrCodeResourceInUseException, "in use", nil), class: approver.ErrInUse},
+		{name: "wrapped error should be classified", err: fmt.Errorf("route53 request failed: %w", awserr.New(route53.ErrCodeNoSuchHostedZone, "missing", nil)), class: approver.ErrInvalidInput},
+		{name: "access denied should not be classified", err: awserr.New("AccessDeniedException", "denied", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			err := approver.ClassifyError(tt.err)
+			assert.Equal(tt.err.Error(), err.Error())
+
+			for _, class := range []error{approver.ErrThrottled, approver.ErrInvalidInput, approver.ErrInUse} {
+				assert.Equal(class == tt.class, errors.Is(err, class), "errors.Is(%v)", class)
+			}
+		})
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	assert := require.New(t)
+
+	assert.True(errors.Is(&approver.TimeoutError{Phase: "waiting"}, approver.ErrTimeout))
+	assert.True(errors.Is(fmt.Errorf("change C1: %w", approver.ErrChangeNotInSync), approver.ErrTimeout))
+	assert.True(errors.Is(fmt.Errorf("ghi789: %w", approver.ErrValidationPending), approver.ErrTimeout))
+	assert.True(errors.Is(&approver.InUseError{CertificateArn: "ghi789"}, approver.ErrInUse))
+	assert.True(errors.Is(&approver.ValidationError{CertificateArn: "ghi789"}, approver.ErrValidationFailed))
+	assert.False(errors.Is(&approver.ValidationError{CertificateArn: "ghi789"}, approver.ErrTimeout))
+}
+
+func TestLogger(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(issuedCertificate, nil).Times(2)
+
+	var approverOutput, requestOutput bytes.Buffer
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithLogger(zerolog.New(&approverOutput)))
+
+	// without a logger in the context the approver's logger is used
+	err := ca.WaitForValidation(context.TODO(), "ghi789")
+	assert.NoError(err)
+	assert.Contains(approverOutput.String(), `"certificateArn":"ghi789"`)
+
+	// a logger in the context is preferred so lines carry the fields identifying the request
+	logger := zerolog.New(&requestOutput).With().Str("RequestID", "abc123").Logger()
+
+	err = ca.WaitForValidation(logger.WithContext(context.TODO()), "ghi789")
+	assert.NoError(err)
+	assert.Contains(requestOutput.String(), `"RequestID":"abc123","certificateArn":"ghi789"`)
+}
+
+func TestWaitForValidation_Metrics(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	acmapi := mocks.NewMockACMAPI(ctrl)
+	route53api := mocks.NewMockRoute53API(ctrl)
+
+	createdAt := time.Now().Add(-3 * time.Minute)
+
+	gomock.InOrder(
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				Status:         aws.String(acm.CertificateStatusPendingValidation),
+			}}, nil),
+		acmapi.EXPECT().DescribeCertificateWithContext(gomock.Any(), gomock.Any()).Return(
+			&acm.DescribeCertificateOutput{Certificate: &acm.CertificateDetail{
+				CertificateArn: aws.String("ghi789"),
+				Status:         aws.String(acm.CertificateStatusIssued),
+				CreatedAt:      aws.Time(createdAt),
+				IssuedAt:       aws.Time(createdAt.Add(2 * time.Minute)),
+			}}, nil),
+	)
+
+	var output bytes.Buffer
+
+	ca := approver.NewWithClients(acmapi, route53api, approver.WithClock(mocks.NewFakeClock(time.Now())), approver.WithMetrics(metrics.New(&output)))
+
+	err := ca.WaitForValidation(context.TODO(), "ghi789")
+	assert.NoError(err)
+
+	assert.Contains(output.String(), `"Operation":"WaitForValidation","Retries":1`)
+	assert.Contains(output.String(), `"Operation":"WaitForValidation","Outcome":"Success","TimeToIssued":120000`)
+}
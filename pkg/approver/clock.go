@@ -0,0 +1,63 @@
+package approver
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Clock provides the current time and sleeps between polls, this is replaced in tests so the
+// polling paths can be exercised without waiting
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// systemClock uses the system time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep waits for the duration, returning early with an error if the context is done
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// waiterOptions configures an aws sdk waiter to poll at the interval using the clock
+func (ac *certificateApprover) waiterOptions(ctx context.Context, interval time.Duration) []request.WaiterOption {
+	return ac.timing.waiterOptions(ctx, ac.clock, interval)
+}
+
+func (t timing) waiterOptions(ctx context.Context, clock Clock, interval time.Duration) []request.WaiterOption {
+	return []request.WaiterOption{
+		request.WithWaiterMaxAttempts(t.maxAttempts),
+		request.WithWaiterDelay(t.waiterDelay(interval)),
+		request.WithWaiterRequestOptions(func(r *request.Request) {
+			r.Config.SleepDelay = func(d time.Duration) {
+				_ = clock.Sleep(ctx, d)
+			}
+		}),
+	}
+}
+
+// hasTimeFor checks the context deadline leaves enough time to wait for the duration and still
+// respond before it expires
+func (ac *certificateApprover) hasTimeFor(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+
+	return deadline.Sub(ac.clock.Now()) > d+deadlineReserve
+}
@@ -0,0 +1,56 @@
+package approver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// each phase of Approve is given a share of the time remaining in the context when it starts, time
+// a phase doesn't use rolls over to the phases after it
+const (
+	describeShare   = 0.15
+	publishShare    = 0.4
+	validationShare = 1.0
+)
+
+// TimeoutError returned when a phase of the approver runs out of its share of the time remaining
+// in the context, this happens before the lambda is killed so the failure can be reported
+type TimeoutError struct {
+	Phase  string
+	Budget time.Duration
+	Err    error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out %s after %s: %v", e.Phase, e.Budget.Round(time.Second), e.Err)
+}
+
+// Unwrap returns the error which caused the timeout
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// runPhase runs fn with a deadline of its share of the time remaining in the context
+func (ac *certificateApprover) runPhase(ctx context.Context, phase string, share float64, fn func(ctx context.Context) error) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fn(ctx)
+	}
+
+	budget := time.Duration(float64(deadline.Sub(ac.clock.Now())) * share)
+
+	zerolog.Ctx(ctx).Info().Str("phase", phase).Dur("budget", budget).Msg("starting phase")
+
+	phaseCtx, cancel := context.WithDeadline(ctx, ac.clock.Now().Add(budget))
+	defer cancel()
+
+	err := fn(phaseCtx)
+	if err != nil && phaseCtx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Phase: phase, Budget: budget, Err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,146 @@
+package approver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+)
+
+// RecordRepair describes a validation record which was missing from, or changed in, its hosted zone
+// and has been restored, Previous holds the values found in the zone and is empty if it was missing
+type RecordRepair struct {
+	HostedZoneID string
+	Name         string
+	Type         string
+	Value        string
+	Previous     []string
+}
+
+// Missing checks the record was missing from the hosted zone rather than changed
+func (r RecordRepair) Missing() bool {
+	return len(r.Previous) == 0
+}
+
+func (ac *certificateApprover) ListManaged(ctx context.Context) ([]string, error) {
+	certificateArns := []string{}
+
+	err := ac.acm.ListCertificatesPagesWithContext(ctx, &acm.ListCertificatesInput{
+		Includes: &acm.Filters{KeyTypes: aws.StringSlice(keyTypes)},
+	}, func(page *acm.ListCertificatesOutput, lastPage bool) bool {
+		for _, summary := range page.CertificateSummaryList {
+			certificateArns = append(certificateArns, aws.StringValue(summary.CertificateArn))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list certificates")
+	}
+
+	managed := []string{}
+
+	for _, certificateArn := range certificateArns {
+		tags, err := ac.Tags(ctx, certificateArn)
+		if err != nil {
+			return nil, err
+		}
+
+		if Managed(tags) {
+			managed = append(managed, certificateArn)
+		}
+	}
+
+	return managed, nil
+}
+
+func (ac *certificateApprover) Repair(ctx context.Context, certificateArn string, zones Zones) ([]RecordRepair, error) {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
+
+	res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(certificateArn),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+	}
+
+	// records for certificates which can no longer be issued or renewed aren't worth restoring
+	switch status := aws.StringValue(res.Certificate.Status); status {
+	case acm.CertificateStatusIssued, acm.CertificateStatusPendingValidation:
+	default:
+		zerolog.Ctx(ctx).Debug().Str("status", status).Msg("skipping certificate which can't be validated")
+		return nil, nil
+	}
+
+	provider := ac.dnsProvider(zones)
+
+	grouped, err := groupRecordsByZone(ctx, provider, res.Certificate.DomainValidationOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	repairs := []RecordRepair{}
+
+	for _, zr := range grouped {
+		drifted, zoneRepairs, err := driftedRecords(ctx, provider, zr)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(drifted.records) == 0 {
+			continue
+		}
+
+		drifted.changes, err = provider.Upsert(ctx, drifted.hostedZoneID, drifted.records)
+		if err != nil {
+			return nil, err
+		}
+
+		err = provider.Wait(ctx, drifted.changes)
+		if err != nil {
+			return nil, err
+		}
+
+		repairs = append(repairs, zoneRepairs...)
+	}
+
+	return repairs, nil
+}
+
+// driftedRecords compares the validation records with those in the zone, returning the records
+// which are missing or have a different value
+func driftedRecords(ctx context.Context, provider dns.Provider, zr *zoneRecords) (*zoneRecords, []RecordRepair, error) {
+	drifted := &zoneRecords{hostedZoneID: zr.hostedZoneID}
+	repairs := []RecordRepair{}
+
+	for _, record := range zr.records {
+		previous, err := provider.Lookup(ctx, zr.hostedZoneID, record)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if inSync(previous, record) {
+			continue
+		}
+
+		drifted.records = append(drifted.records, record)
+		repairs = append(repairs, RecordRepair{
+			HostedZoneID: zr.hostedZoneID,
+			Name:         fqdn(record.Name),
+			Type:         record.Type,
+			Value:        record.Value,
+			Previous:     previous,
+		})
+	}
+
+	return drifted, repairs, nil
+}
+
+// inSync checks the values in the zone are those of the validation record
+func inSync(values []string, record dns.Record) bool {
+	return len(values) == 1 && strings.EqualFold(fqdn(values[0]), fqdn(record.Value))
+}
@@ -0,0 +1,147 @@
+package approver
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+)
+
+// the classes of errors returned by the approver, use errors.Is to check which class an error belongs to
+var (
+	// ErrThrottled AWS throttled a request after the sdk exhausted its retries
+	ErrThrottled = errors.New("request throttled")
+	// ErrInvalidInput the names, hosted zones or other values supplied can't be used
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrValidationFailed ACM stopped validating the certificate without issuing it
+	ErrValidationFailed = errors.New("certificate validation failed")
+	// ErrInUse the certificate is still in use by other resources
+	ErrInUse = errors.New("certificate in use")
+	// ErrTimeout the approver ran out of time or attempts before the operation completed
+	ErrTimeout = errors.New("timed out")
+)
+
+// invalidInputCodes the AWS error codes caused by the input to a request
+var invalidInputCodes = map[string]bool{
+	acm.ErrCodeInvalidArgsException:                    true,
+	acm.ErrCodeInvalidArnException:                     true,
+	acm.ErrCodeInvalidDomainValidationOptionsException: true,
+	acm.ErrCodeInvalidParameterException:               true,
+	acm.ErrCodeInvalidTagException:                     true,
+	acm.ErrCodeTagPolicyException:                      true,
+	acm.ErrCodeTooManyTagsException:                    true,
+	acm.ErrCodeResourceNotFoundException:               true,
+	route53.ErrCodeInvalidArgument:                     true,
+	route53.ErrCodeInvalidChangeBatch:                  true,
+	route53.ErrCodeInvalidDomainName:                   true,
+	route53.ErrCodeInvalidInput:                        true,
+	route53.ErrCodeNoSuchHostedZone:                    true,
+	"ValidationException":                              true,
+}
+
+// classifiedError an error which belongs to one of the classes of errors
+type classifiedError struct {
+	msg   string
+	class error
+}
+
+func (e *classifiedError) Error() string {
+	return e.msg
+}
+
+// Is reports whether the error belongs to the target class
+func (e *classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+func invalidInputf(format string, args ...interface{}) error {
+	return &classifiedError{msg: fmt.Sprintf(format, args...), class: ErrInvalidInput}
+}
+
+// APIError returned when an AWS request fails with an error which is either throttling or caused by the input
+type APIError struct {
+	Class error
+	Err   error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the AWS error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error belongs to the target class
+func (e *APIError) Is(target error) bool {
+	return target == e.Class
+}
+
+// Is reports whether the target is ErrTimeout
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// Is reports whether the target is ErrInUse
+func (e *InUseError) Is(target error) bool {
+	return target == ErrInUse
+}
+
+// Is reports whether the target is ErrValidationFailed
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidationFailed
+}
+
+// Outcome names the class of the error for use in metrics, Success is returned for a nil error
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, ErrThrottled):
+		return "Throttled"
+	case errors.Is(err, ErrInvalidInput):
+		return "InvalidInput"
+	case errors.Is(err, ErrValidationFailed):
+		return "ValidationFailed"
+	case errors.Is(err, ErrInUse):
+		return "InUse"
+	case errors.Is(err, ErrTimeout):
+		return "Timeout"
+	}
+
+	return "Error"
+}
+
+// classifyError wraps AWS errors in an APIError when they belong to one of the classes of errors
+func classifyError(err error) error {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return err
+	}
+
+	switch {
+	case request.IsErrorThrottle(aerr):
+		return &APIError{Class: ErrThrottled, Err: err}
+	case invalidInputCodes[aerr.Code()]:
+		return &APIError{Class: ErrInvalidInput, Err: err}
+	case aerr.Code() == acm.ErrCodeResourceInUseException:
+		return &APIError{Class: ErrInUse, Err: err}
+	}
+
+	return err
+}
+
+// classifyErrors classifies the errors returned by requests made using the handlers
+func classifyErrors(handlers *request.Handlers) {
+	handlers.Complete.PushBack(func(r *request.Request) {
+		if r.Error != nil {
+			r.Error = classifyError(r.Error)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package approver
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+)
+
+// NewWithClients creates an approver using the supplied clients, this is used by tests to supply mocks
+func NewWithClients(acmapi acmiface.ACMAPI, route53api route53iface.Route53API, opts ...Option) Certificate {
+	o := newOptions(opts...)
+
+	return withTracing(&certificateApprover{acm: acmapi, route53: route53api, provider: o.provider, regionalACM: o.regionalACM, timing: o.timing, clock: o.clock, logger: o.logger, metrics: o.metrics})
+}
+
+// WithRegionalClients supplies the ACM clients for the other regions, this is used by tests to supply mocks
+func WithRegionalClients(fn func(ctx context.Context) ([]acmiface.ACMAPI, error)) Option {
+	return func(o *options) {
+		o.regionalACM = fn
+	}
+}
+
+// BatchChanges exported for testing
+var BatchChanges = batchChanges
+
+// PollDelay exported for testing
+func PollDelay(opts []Option, interval time.Duration, attempt int) time.Duration {
+	return newOptions(opts...).timing.pollDelay(interval, attempt)
+}
+
+// CAAAllowsAmazon exported for testing
+var CAAAllowsAmazon = caaAllowsAmazon
+
+// ClassifyError exported for testing
+var ClassifyError = classifyError
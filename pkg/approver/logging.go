@@ -0,0 +1,24 @@
+package approver
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// withLogger ensures the context carries a logger, one added by the caller using zerolog's WithContext
+// is preferred as it has fields identifying the request, otherwise the approver's logger is used
+func (ac *certificateApprover) withLogger(ctx context.Context) context.Context {
+	if zerolog.Ctx(ctx).GetLevel() != zerolog.Disabled {
+		return ctx
+	}
+
+	return ac.logger.WithContext(ctx)
+}
+
+// withCertificateLogger adds the certificate arn to the logger carried by the context
+func (ac *certificateApprover) withCertificateLogger(ctx context.Context, certificateArn string) context.Context {
+	logger := zerolog.Ctx(ac.withLogger(ctx)).With().Str("certificateArn", certificateArn).Logger()
+
+	return logger.WithContext(ctx)
+}
@@ -0,0 +1,47 @@
+package approver
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+)
+
+// metric names recorded by the approver
+const (
+	metricPublishLatency = "PublishLatency"
+	metricTimeToIssued   = "TimeToIssued"
+	metricDeleteWait     = "DeleteWait"
+	metricRetries        = "Retries"
+)
+
+// recordDuration records the time since start along with the outcome of the operation
+func (ac *certificateApprover) recordDuration(name, operation string, start time.Time, err error) {
+	ac.metrics.Duration(name, ac.clock.Now().Sub(start), metrics.Dimensions{
+		metrics.Operation: operation,
+		metrics.Outcome:   Outcome(err),
+	})
+}
+
+// recordRetries records the number of polls made after the first
+func (ac *certificateApprover) recordRetries(operation string, attempts int) {
+	if attempts < 1 {
+		return
+	}
+
+	ac.metrics.Count(metricRetries, attempts-1, metrics.Dimensions{metrics.Operation: operation})
+}
+
+// recordTimeToIssued records the time ACM took to issue the certificate after it was requested
+func (ac *certificateApprover) recordTimeToIssued(cert *acm.CertificateDetail) {
+	if cert.CreatedAt == nil || cert.IssuedAt == nil {
+		return
+	}
+
+	ac.metrics.Duration(metricTimeToIssued, aws.TimeValue(cert.IssuedAt).Sub(aws.TimeValue(cert.CreatedAt)), metrics.Dimensions{
+		metrics.Operation: "WaitForValidation",
+		metrics.Outcome:   metrics.OutcomeSuccess,
+	})
+}
@@ -0,0 +1,195 @@
+package approver
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+)
+
+const (
+	defaultMaxAttempts        = 20
+	defaultRecordTTLSeconds   = 60
+	defaultDescribePollTime   = 5 * time.Second
+	defaultValidationPollTime = 30 * time.Second
+	defaultChangePollTime     = 10 * time.Second
+	defaultDeletionPollTime   = 30 * time.Second
+)
+
+// Option configures the approver
+type Option func(*options)
+
+type options struct {
+	configs           []*aws.Config
+	route53RoleArn    string
+	route53ExternalID string
+	provider          dns.Provider
+	regionalACM       regionalClients
+	timing            timing
+	clock             Clock
+	logger            zerolog.Logger
+	metrics           *metrics.Metrics
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		timing: timing{
+			maxAttempts:        defaultMaxAttempts,
+			recordTTLSeconds:   defaultRecordTTLSeconds,
+			describePollTime:   defaultDescribePollTime,
+			validationPollTime: defaultValidationPollTime,
+			changePollTime:     defaultChangePollTime,
+			deletionPollTime:   defaultDeletionPollTime,
+		},
+		clock:  systemClock{},
+		logger: log.Logger,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithConfig adds aws configuration used to create the ACM and Route53 clients
+func WithConfig(config *aws.Config) Option {
+	return func(o *options) {
+		o.configs = append(o.configs, config)
+	}
+}
+
+// WithRoute53Role assumes the supplied role for all Route53 calls, this is used to update hosted
+// zones which live in another account while certificates stay in the local account
+func WithRoute53Role(roleArn, externalID string) Option {
+	return func(o *options) {
+		o.route53RoleArn = roleArn
+		o.route53ExternalID = externalID
+	}
+}
+
+// WithDNSProvider publishes validation records using the supplied provider rather than route53, the
+// provider resolves the zone for each record so the hosted zones selected by Zones are ignored
+func WithDNSProvider(provider dns.Provider) Option {
+	return func(o *options) {
+		o.provider = provider
+	}
+}
+
+// WithMaxAttempts sets the number of times each poll is attempted before giving up, defaults to 20
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(o *options) {
+		o.timing.maxAttempts = maxAttempts
+	}
+}
+
+// WithRecordTTL sets the TTL in seconds of the published validation records, defaults to 60
+func WithRecordTTL(ttlSeconds int64) Option {
+	return func(o *options) {
+		o.timing.recordTTLSeconds = ttlSeconds
+	}
+}
+
+// WithDescribePollTime sets the interval between checks for the validation records of a newly
+// requested certificate, defaults to 5 seconds
+func WithDescribePollTime(d time.Duration) Option {
+	return func(o *options) {
+		o.timing.describePollTime = d
+	}
+}
+
+// WithChangePollTime sets the interval between checks for route53 changes to be INSYNC, defaults to 10 seconds
+func WithChangePollTime(d time.Duration) Option {
+	return func(o *options) {
+		o.timing.changePollTime = d
+	}
+}
+
+// WithValidationPollTime sets the interval between checks for the certificate to be issued, defaults to 30 seconds
+func WithValidationPollTime(d time.Duration) Option {
+	return func(o *options) {
+		o.timing.validationPollTime = d
+	}
+}
+
+// WithDeletionPollTime sets the interval between checks for the certificate to no longer be in use, defaults to 30 seconds
+func WithDeletionPollTime(d time.Duration) Option {
+	return func(o *options) {
+		o.timing.deletionPollTime = d
+	}
+}
+
+// WithBackoff enables exponential backoff, each poll interval doubles with every attempt up to
+// maxDelay, with jitter applied to spread out calls from approvers running at the same time
+func WithBackoff(maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.timing.backoffMaxDelay = maxDelay
+	}
+}
+
+// WithClock replaces the clock used to sleep between polls and measure elapsed time
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithLogger replaces the logger used when the context passed to the approver doesn't carry one
+func WithLogger(logger zerolog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMetrics records metrics for the approver, by default metrics are discarded
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// timing controls how often the approver polls and how many attempts it makes
+type timing struct {
+	maxAttempts        int
+	recordTTLSeconds   int64
+	describePollTime   time.Duration
+	validationPollTime time.Duration
+	changePollTime     time.Duration
+	deletionPollTime   time.Duration
+	backoffMaxDelay    time.Duration
+}
+
+// pollDelay returns the delay before the next poll, attempts start at 1
+func (t timing) pollDelay(interval time.Duration, attempt int) time.Duration {
+	if t.backoffMaxDelay <= 0 || interval <= 0 {
+		return interval
+	}
+
+	d := interval
+
+	for i := 1; i < attempt && d < t.backoffMaxDelay; i++ {
+		d *= 2
+	}
+
+	if d > t.backoffMaxDelay {
+		d = t.backoffMaxDelay
+	}
+
+	// equal jitter keeps half the delay and randomises the rest
+	half := d / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1)) // nolint:gosec
+}
+
+// waiterDelay adapts pollDelay for use with the aws sdk waiters
+func (t timing) waiterDelay(interval time.Duration) request.WaiterDelay {
+	return func(attempt int) time.Duration {
+		return t.pollDelay(interval, attempt)
+	}
+}
@@ -0,0 +1,300 @@
+package approver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+)
+
+// actions taken on validation records in a plan
+const (
+	RecordUpsert = dns.ActionUpsert
+	RecordDelete = dns.ActionDelete
+	RecordSkip   = "SKIP"
+)
+
+// Plan describes the mutating ACM and DNS calls the approver would make, and the validation
+// records it would change, without making them
+type Plan struct {
+	Calls   []PlannedCall
+	Records []PlannedRecord
+	Notes   []string
+}
+
+// PlannedCall a mutating API call the approver would make
+type PlannedCall struct {
+	Service   string
+	Operation string
+	Target    string
+}
+
+// PlannedRecord a validation record the approver would change, Name and Value are empty when ACM
+// hasn't generated the record yet, Conflicts holds the values in the hosted zone which would be replaced
+type PlannedRecord struct {
+	Action       string
+	HostedZoneID string
+	DomainName   string
+	Name         string
+	Type         string
+	Value        string
+	Conflicts    []string
+	Reason       string
+}
+
+func (p *Plan) String() string {
+	lines := []string{}
+
+	for _, call := range p.Calls {
+		lines = append(lines, fmt.Sprintf("%s %s %s", call.Service, call.Operation, call.Target))
+	}
+
+	for _, record := range p.Records {
+		name := record.Name
+		if name == "" {
+			name = "(generated by ACM for " + record.DomainName + ")"
+		}
+
+		line := fmt.Sprintf("%s %s %s in zone %s", record.Action, record.Type, name, record.HostedZoneID)
+
+		if record.Value != "" {
+			line += " -> " + record.Value
+		}
+
+		if len(record.Conflicts) > 0 {
+			line += " replacing " + strings.Join(record.Conflicts, ", ")
+		}
+
+		if record.Reason != "" {
+			line += " (" + record.Reason + ")"
+		}
+
+		lines = append(lines, line)
+	}
+
+	for _, note := range p.Notes {
+		lines = append(lines, "note: "+note)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// addChanges records the call the provider makes to change the records in a zone
+func (p *Plan) addChanges(provider dns.Provider, hostedZoneID string, action string, records int) {
+	if records == 0 {
+		return
+	}
+
+	operation := provider.Operation(action)
+
+	p.Calls = append(p.Calls, PlannedCall{
+		Service:   operation.Service,
+		Operation: operation.Name,
+		Target:    fmt.Sprintf("%s (%d %s)", hostedZoneID, records, action),
+	})
+}
+
+func (ac *certificateApprover) PlanRequest(ctx context.Context, domainName string, subjectAlternativeNames []string, zones Zones) (*Plan, error) {
+	ctx = ac.withLogger(ctx)
+
+	err := ac.Preflight(ctx, domainName, subjectAlternativeNames, zones)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Calls: []PlannedCall{{Service: "acm", Operation: "RequestCertificate", Target: strings.Join(sortedNames(nameSet(domainName, subjectAlternativeNames)), ", ")}},
+	}
+
+	provider := ac.dnsProvider(zones)
+	upserts := map[string]int{}
+	zoneOrder := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range sortedNames(nameSet(domainName, subjectAlternativeNames)) {
+		// wildcards are validated using the same record as the name they cover
+		name = fqdn(strings.TrimPrefix(name, "*."))
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+
+		zoneID, err := provider.Zone(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := upserts[zoneID]; !ok {
+			zoneOrder = append(zoneOrder, zoneID)
+		}
+
+		upserts[zoneID]++
+
+		plan.Records = append(plan.Records, PlannedRecord{
+			Action:       RecordUpsert,
+			HostedZoneID: zoneID,
+			DomainName:   name,
+			Type:         route53.RRTypeCname,
+		})
+	}
+
+	for _, zoneID := range zoneOrder {
+		plan.addChanges(provider, zoneID, RecordUpsert, upserts[zoneID])
+	}
+
+	return plan, nil
+}
+
+func (ac *certificateApprover) PlanApprove(ctx context.Context, certificateArn string, zones Zones) (*Plan, error) {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
+
+	res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(certificateArn),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+	}
+
+	plan := &Plan{}
+	provider := ac.dnsProvider(zones)
+
+	grouped, err := groupRecordsByZone(ctx, provider, res.Certificate.DomainValidationOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending := pendingDomains(res.Certificate.DomainValidationOptions); len(pending) > 0 {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("ACM hasn't generated the validation records for %s yet", strings.Join(pending, ", ")))
+	} else if len(grouped) == 0 {
+		plan.Notes = append(plan.Notes, "ACM hasn't generated the validation records yet")
+	}
+
+	for _, zr := range grouped {
+		upserts := 0
+
+		for _, record := range zr.records {
+			values, err := provider.Lookup(ctx, zr.hostedZoneID, record)
+			if err != nil {
+				return nil, err
+			}
+
+			planned := plannedRecord(zr.hostedZoneID, record, values)
+
+			if inSync(values, record) {
+				planned.Action, planned.Reason = RecordSkip, "already in the hosted zone"
+			} else {
+				planned.Action = RecordUpsert
+				upserts++
+			}
+
+			plan.Records = append(plan.Records, planned)
+		}
+
+		plan.addChanges(provider, zr.hostedZoneID, RecordUpsert, upserts)
+	}
+
+	if status := aws.StringValue(res.Certificate.Status); status != acm.CertificateStatusIssued {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("certificate is %s, approve would wait for it to be issued", status))
+	}
+
+	return plan, nil
+}
+
+func (ac *certificateApprover) PlanDelete(ctx context.Context, certificateArn string, zones Zones, policy InUsePolicy) (*Plan, error) {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
+
+	res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(certificateArn),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+	}
+
+	plan := &Plan{
+		Calls: []PlannedCall{{Service: "acm", Operation: "DeleteCertificate", Target: certificateArn}},
+	}
+
+	if inUseBy := aws.StringValueSlice(res.Certificate.InUseBy); len(inUseBy) > 0 {
+		outcome := map[InUsePolicy]string{
+			InUseFail:   "fail",
+			InUseRetain: "retain it",
+			InUseWait:   "fail once the lambda deadline is reached",
+		}[policy]
+
+		plan.Notes = append(plan.Notes, fmt.Sprintf("certificate is in use by %s, delete would wait for it to be released then %s",
+			strings.Join(inUseBy, ", "), outcome))
+	}
+
+	provider := ac.dnsProvider(zones)
+
+	grouped, err := groupRecordsByZone(ctx, provider, res.Certificate.DomainValidationOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grouped) == 0 {
+		return plan, nil
+	}
+
+	referenced, err := ac.referencedRecordNames(ctx, certificateArn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, zr := range grouped {
+		deletes := 0
+
+		for _, record := range zr.records {
+			if referenced[fqdn(record.Name)] {
+				planned := plannedRecord(zr.hostedZoneID, record, nil)
+				planned.Action, planned.Reason = RecordSkip, "referenced by another certificate"
+				plan.Records = append(plan.Records, planned)
+				continue
+			}
+
+			values, err := provider.Lookup(ctx, zr.hostedZoneID, record)
+			if err != nil {
+				return nil, err
+			}
+
+			planned := plannedRecord(zr.hostedZoneID, record, values)
+
+			if values == nil {
+				planned.Action, planned.Reason = RecordSkip, "not found in the hosted zone"
+			} else {
+				planned.Action = RecordDelete
+				deletes++
+			}
+
+			plan.Records = append(plan.Records, planned)
+		}
+
+		plan.addChanges(provider, zr.hostedZoneID, RecordDelete, deletes)
+	}
+
+	return plan, nil
+}
+
+// plannedRecord describes the validation record, any values in the hosted zone which differ from it
+// are recorded as conflicts
+func plannedRecord(hostedZoneID string, record dns.Record, values []string) PlannedRecord {
+	planned := PlannedRecord{
+		HostedZoneID: hostedZoneID,
+		Name:         fqdn(record.Name),
+		Type:         record.Type,
+		Value:        record.Value,
+	}
+
+	if values != nil && !inSync(values, record) {
+		planned.Conflicts = values
+	}
+
+	return planned
+}
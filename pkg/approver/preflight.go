@@ -0,0 +1,228 @@
+package approver
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// caaIssuers the CAA issuer domains which allow ACM to issue certificates
+var caaIssuers = []string{"amazon.com", "amazontrust.com", "awstrust.com", "amazonaws.com"}
+
+func (ac *certificateApprover) Preflight(ctx context.Context, domainName string, subjectAlternativeNames []string, zones Zones) error {
+	ctx = ac.withLogger(ctx)
+
+	if ac.provider != nil {
+		return ac.preflightProvider(ctx, domainName, subjectAlternativeNames)
+	}
+
+	resolver := newZoneResolver(ac.route53, zones)
+	hostedZones := map[string]*route53.HostedZone{}
+	caa := map[string][]string{}
+
+	for _, name := range sortedNames(nameSet(domainName, subjectAlternativeNames)) {
+		zoneID, err := resolver.Resolve(ctx, strings.TrimPrefix(name, "*."))
+		if err != nil {
+			return err
+		}
+
+		zone, ok := hostedZones[zoneID]
+		if !ok {
+			zone, err = ac.getHostedZone(ctx, zoneID)
+			if err != nil {
+				return err
+			}
+
+			hostedZones[zoneID] = zone
+		}
+
+		err = checkZone(zone, name)
+		if err != nil {
+			return err
+		}
+
+		err = ac.checkCAA(ctx, zone, name, caa)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// preflightProvider checks the configured dns.Provider has a zone for each name, the hosted zone and CAA
+// checks rely on route53 so are skipped
+func (ac *certificateApprover) preflightProvider(ctx context.Context, domainName string, subjectAlternativeNames []string) error {
+	zerolog.Ctx(ctx).Info().Msg("skipping hosted zone and CAA checks which rely on route53")
+
+	for _, name := range sortedNames(nameSet(domainName, subjectAlternativeNames)) {
+		_, err := ac.provider.Zone(ctx, strings.TrimPrefix(name, "*."))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ac *certificateApprover) getHostedZone(ctx context.Context, hostedZoneID string) (*route53.HostedZone, error) {
+	res, err := ac.route53.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{
+		Id: aws.String(hostedZoneID),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get hosted zone %s", hostedZoneID)
+	}
+
+	return res.HostedZone, nil
+}
+
+// checkZone checks the name is within the hosted zone, and the zone is public as ACM can't see
+// validation records in private hosted zones
+func checkZone(zone *route53.HostedZone, name string) error {
+	zoneID, zoneName := trimHostedZoneID(aws.StringValue(zone.Id)), fqdn(aws.StringValue(zone.Name))
+
+	if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+		return invalidInputf("hosted zone %s (%s) is private, ACM can't validate %s using records in a private hosted zone", zoneID, zoneName, name)
+	}
+
+	if !inZone(fqdn(strings.TrimPrefix(name, "*.")), zoneName) {
+		return invalidInputf("%s is not within hosted zone %s (%s)", name, zoneID, zoneName)
+	}
+
+	return nil
+}
+
+// inZone checks the fully qualified name is the zone apex or below it
+func inZone(name, zoneName string) bool {
+	return name == zoneName || strings.HasSuffix(name, "."+zoneName)
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// checkCAA finds the closest CAA record set to the name within the zone, as a CA would, and checks it
+// allows ACM to issue certificates for the name, names without any CAA records are unrestricted
+func (ac *certificateApprover) checkCAA(ctx context.Context, zone *route53.HostedZone, name string, caa map[string][]string) error {
+	hostedZoneID, zoneName := trimHostedZoneID(aws.StringValue(zone.Id)), fqdn(aws.StringValue(zone.Name))
+	wildcard := strings.HasPrefix(name, "*.")
+
+	for _, recordName := range parentNames(strings.TrimPrefix(name, "*.")) {
+		// names above the apex aren't in this hosted zone
+		if !inZone(recordName, zoneName) {
+			break
+		}
+
+		values, ok := caa[recordName]
+		if !ok {
+			var err error
+
+			values, err = ac.listCAA(ctx, hostedZoneID, recordName)
+			if err != nil {
+				return err
+			}
+
+			caa[recordName] = values
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		if !caaAllowsAmazon(values, wildcard) {
+			return invalidInputf("CAA records on %s don't allow ACM to issue a certificate for %s, one of %s must be allowed: %s",
+				recordName, name, strings.Join(caaIssuers, ", "), strings.Join(values, ", "))
+		}
+
+		zerolog.Ctx(ctx).Info().Str("name", name).Str("record", recordName).Msg("CAA records allow ACM to issue certificates")
+
+		return nil
+	}
+
+	return nil
+}
+
+// listCAA returns the values of the CAA record set with the name in the hosted zone
+func (ac *certificateApprover) listCAA(ctx context.Context, hostedZoneID, recordName string) ([]string, error) {
+	res, err := ac.route53.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(recordName),
+		StartRecordType: aws.String(route53.RRTypeCaa),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list CAA records named %s in zone %s", recordName, hostedZoneID)
+	}
+
+	values := []string{}
+
+	for _, rrs := range res.ResourceRecordSets {
+		if fqdn(aws.StringValue(rrs.Name)) != recordName || aws.StringValue(rrs.Type) != route53.RRTypeCaa {
+			continue
+		}
+
+		for _, rr := range rrs.ResourceRecords {
+			values = append(values, aws.StringValue(rr.Value))
+		}
+	}
+
+	return values, nil
+}
+
+// caaAllowsAmazon checks the CAA values allow one of the amazon issuers, wildcard names use the
+// issuewild properties when there are any, otherwise the issue properties
+func caaAllowsAmazon(values []string, wildcard bool) bool {
+	issue, issueWild := []string{}, []string{}
+
+	for _, value := range values {
+		fields := strings.Fields(value)
+		if len(fields) < 3 {
+			continue
+		}
+
+		issuer := strings.Trim(strings.Join(fields[2:], " "), `"`)
+
+		// parameters follow the issuer domain after a semicolon
+		issuer = strings.TrimSpace(strings.SplitN(issuer, ";", 2)[0])
+
+		switch strings.ToLower(fields[1]) {
+		case "issue":
+			issue = append(issue, issuer)
+		case "issuewild":
+			issueWild = append(issueWild, issuer)
+		}
+	}
+
+	issuers := issue
+	if wildcard && len(issueWild) > 0 {
+		issuers = issueWild
+	}
+
+	// only iodef or other properties are present so issuance isn't restricted
+	if len(issuers) == 0 {
+		return true
+	}
+
+	for _, issuer := range issuers {
+		for _, allowed := range caaIssuers {
+			if strings.EqualFold(issuer, allowed) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
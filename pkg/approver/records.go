@@ -0,0 +1,77 @@
+package approver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/rs/zerolog"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+)
+
+// zoneRecords validation records which are published into a single zone
+type zoneRecords struct {
+	hostedZoneID string
+	records      []dns.Record
+	changes      []dns.Change
+}
+
+// groupRecordsByZone resolves the zone for each validation record, zones are returned in the order
+// they are first referenced by the certificate. Records are deduplicated as ACM returns the same
+// record for names such as example.com and *.example.com.
+func groupRecordsByZone(ctx context.Context, provider dns.Provider, validations []*acm.DomainValidation) ([]*zoneRecords, error) {
+	grouped := []*zoneRecords{}
+	byZone := map[string]*zoneRecords{}
+	seen := map[string]bool{}
+
+	for _, domainValidation := range validations {
+		if domainValidation.ResourceRecord == nil {
+			continue
+		}
+
+		record := validationRecord(domainValidation.ResourceRecord)
+
+		key := recordKey(record)
+		if seen[key] {
+			zerolog.Ctx(ctx).Debug().Str("record", record.Name).Msg("skipping duplicate validation record")
+			continue
+		}
+
+		seen[key] = true
+
+		zoneID, err := provider.Zone(ctx, record.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		zr, ok := byZone[zoneID]
+		if !ok {
+			zr = &zoneRecords{hostedZoneID: zoneID}
+			byZone[zoneID] = zr
+			grouped = append(grouped, zr)
+		}
+
+		zr.records = append(zr.records, record)
+	}
+
+	return grouped, nil
+}
+
+func validationRecord(record *acm.ResourceRecord) dns.Record {
+	return dns.Record{
+		Name:  aws.StringValue(record.Name),
+		Type:  aws.StringValue(record.Type),
+		Value: aws.StringValue(record.Value),
+	}
+}
+
+// recordKey identifies a record by name, type and value
+func recordKey(record dns.Record) string {
+	return strings.Join([]string{
+		fqdn(record.Name),
+		strings.ToUpper(record.Type),
+		record.Value,
+	}, " ")
+}
@@ -0,0 +1,47 @@
+package approver
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/pkg/errors"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
+)
+
+// regionalClients returns ACM clients for the enabled regions of the account other than the region of
+// the approver, ACM uses the same validation record for a domain in every region
+type regionalClients func(ctx context.Context) ([]acmiface.ACMAPI, error)
+
+func newRegionalClients(sess *session.Session, ec2api ec2iface.EC2API) regionalClients {
+	return func(ctx context.Context) ([]acmiface.ACMAPI, error) {
+		// without AllRegions only the regions enabled for the account are returned
+		res, err := ec2api.DescribeRegionsWithContext(ctx, &ec2.DescribeRegionsInput{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the enabled regions")
+		}
+
+		clients := []acmiface.ACMAPI{}
+
+		for _, region := range res.Regions {
+			name := aws.StringValue(region.RegionName)
+			if name == aws.StringValue(sess.Config.Region) {
+				continue
+			}
+
+			acmsvc := acm.New(sess, aws.NewConfig().WithRegion(name))
+
+			classifyErrors(&acmsvc.Handlers)
+			tracing.AddHandlers(&acmsvc.Handlers)
+
+			clients = append(clients, acmsvc)
+		}
+
+		return clients, nil
+	}
+}
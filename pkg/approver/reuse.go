@@ -0,0 +1,88 @@
+package approver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func (ac *certificateApprover) FindIssued(ctx context.Context, domainName string, subjectAlternativeNames []string) (string, error) {
+	ctx = ac.withLogger(ctx)
+
+	names := nameSet(domainName, subjectAlternativeNames)
+
+	certificateArns := []string{}
+
+	err := ac.acm.ListCertificatesPagesWithContext(ctx, &acm.ListCertificatesInput{
+		CertificateStatuses: aws.StringSlice([]string{acm.CertificateStatusIssued}),
+		Includes:            &acm.Filters{KeyTypes: aws.StringSlice(keyTypes)},
+	}, func(page *acm.ListCertificatesOutput, lastPage bool) bool {
+		for _, summary := range page.CertificateSummaryList {
+			if strings.EqualFold(aws.StringValue(summary.DomainName), domainName) {
+				certificateArns = append(certificateArns, aws.StringValue(summary.CertificateArn))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list certificates")
+	}
+
+	for _, certificateArn := range certificateArns {
+		res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+			CertificateArn: aws.String(certificateArn),
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+		}
+
+		cert := res.Certificate
+
+		// imported certificates aren't renewed by ACM so they are never reused
+		if aws.StringValue(cert.Type) != acm.CertificateTypeAmazonIssued || aws.StringValue(cert.Status) != acm.CertificateStatusIssued {
+			continue
+		}
+
+		if !sameNames(names, nameSet(aws.StringValue(cert.DomainName), aws.StringValueSlice(cert.SubjectAlternativeNames))) {
+			continue
+		}
+
+		zerolog.Ctx(ctx).Info().Str("certificateArn", certificateArn).Str("domainName", domainName).Msg("found existing issued certificate")
+
+		return certificateArn, nil
+	}
+
+	return "", nil
+}
+
+// nameSet returns the lower cased names covered by a certificate, ACM includes the domain name in
+// the subject alternative names so it is added here to allow either form to match
+func nameSet(domainName string, subjectAlternativeNames []string) map[string]bool {
+	names := map[string]bool{strings.ToLower(domainName): true}
+
+	for _, name := range subjectAlternativeNames {
+		if name != "" {
+			names[strings.ToLower(name)] = true
+		}
+	}
+
+	return names
+}
+
+func sameNames(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name := range a {
+		if !b[name] {
+			return false
+		}
+	}
+
+	return true
+}
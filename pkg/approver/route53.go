@@ -0,0 +1,261 @@
+package approver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/dns"
+)
+
+const (
+	// route53 limits for a single ChangeResourceRecordSets request
+	maxBatchRecords = 1000
+	maxBatchChars   = 32000
+)
+
+// ErrChangeNotInSync returned when a route53 change doesn't propagate before the wait gives up, it
+// belongs to the ErrTimeout class
+var ErrChangeNotInSync error = &classifiedError{msg: "route53 change did not reach INSYNC", class: ErrTimeout}
+
+// Route53Provider publishes validation records into the route53 hosted zones selected by zones, it
+// is the dns.Provider used unless another is configured
+type Route53Provider struct {
+	route53  route53iface.Route53API
+	resolver *zoneResolver
+	timing   timing
+	clock    Clock
+}
+
+// NewRoute53Provider creates a dns.Provider which publishes validation records into the route53
+// hosted zones selected by zones, the record TTL, change poll time, max attempts, backoff and clock
+// options of the approver also apply to the provider
+func NewRoute53Provider(route53api route53iface.Route53API, zones Zones, opts ...Option) *Route53Provider {
+	o := newOptions(opts...)
+
+	return newRoute53Provider(route53api, zones, o.timing, o.clock)
+}
+
+func newRoute53Provider(route53api route53iface.Route53API, zones Zones, t timing, clock Clock) *Route53Provider {
+	return &Route53Provider{
+		route53:  route53api,
+		resolver: newZoneResolver(route53api, zones),
+		timing:   t,
+		clock:    clock,
+	}
+}
+
+// dnsProvider returns the configured dns.Provider, otherwise route53 using the hosted zones selected by zones
+func (ac *certificateApprover) dnsProvider(zones Zones) dns.Provider {
+	if ac.provider != nil {
+		return ac.provider
+	}
+
+	return newRoute53Provider(ac.route53, zones, ac.timing, ac.clock)
+}
+
+// Operation route53 applies both upserts and deletes using ChangeResourceRecordSets
+func (rp *Route53Provider) Operation(action string) dns.Operation {
+	return dns.Operation{Service: "route53", Name: "ChangeResourceRecordSets"}
+}
+
+func (rp *Route53Provider) Zone(ctx context.Context, recordName string) (string, error) {
+	return rp.resolver.Resolve(ctx, recordName)
+}
+
+func (rp *Route53Provider) Lookup(ctx context.Context, hostedZoneID string, record dns.Record) ([]string, error) {
+	rrs, err := rp.recordSet(ctx, hostedZoneID, record)
+	if err != nil || rrs == nil {
+		return nil, err
+	}
+
+	var values []string
+
+	for _, rr := range rrs.ResourceRecords {
+		values = append(values, aws.StringValue(rr.Value))
+	}
+
+	return values, nil
+}
+
+// Upsert submits all the records in one change batch unless this exceeds the route53 limits
+func (rp *Route53Provider) Upsert(ctx context.Context, hostedZoneID string, records []dns.Record) ([]dns.Change, error) {
+	changes := []*route53.Change{}
+
+	for _, record := range records {
+		zerolog.Ctx(ctx).Info().Msgf("Upserting DNS record into zone %s: %s %s %s",
+			hostedZoneID, record.Name, record.Type, record.Value)
+
+		changes = append(changes, &route53.Change{
+			Action: aws.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name: aws.String(record.Name),
+				Type: aws.String(record.Type),
+				TTL:  aws.Int64(rp.timing.recordTTLSeconds),
+				ResourceRecords: []*route53.ResourceRecord{
+					{
+						Value: aws.String(record.Value),
+					},
+				},
+			},
+		})
+	}
+
+	return rp.changeRecords(ctx, hostedZoneID, changes)
+}
+
+func (rp *Route53Provider) Delete(ctx context.Context, hostedZoneID string, records []dns.Record) ([]dns.Change, error) {
+	changes := []*route53.Change{}
+
+	for _, record := range records {
+		name := fqdn(record.Name)
+
+		// deletes must match the existing record exactly so look it up
+		rrs, err := rp.recordSet(ctx, hostedZoneID, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if rrs == nil {
+			zerolog.Ctx(ctx).Info().Str("record", name).Msg("validation record not found, skipping")
+			continue
+		}
+
+		zerolog.Ctx(ctx).Info().Msgf("Deleting DNS record from zone %s: %s %s %s",
+			hostedZoneID, name, record.Type, record.Value)
+
+		changes = append(changes, &route53.Change{
+			Action:            aws.String(route53.ChangeActionDelete),
+			ResourceRecordSet: rrs,
+		})
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return rp.changeRecords(ctx, hostedZoneID, changes)
+}
+
+// Wait waits for each change to be INSYNC, which indicates it has propagated to all the route53 DNS servers
+func (rp *Route53Provider) Wait(ctx context.Context, changes []dns.Change) error {
+	start := rp.clock.Now()
+
+	for _, change := range changes {
+		zerolog.Ctx(ctx).Info().Str("hostedZoneId", change.ZoneID).Str("changeId", change.ID).Msg("waiting for change to be INSYNC")
+
+		err := rp.route53.WaitUntilResourceRecordSetsChangedWithContext(ctx, &route53.GetChangeInput{
+			Id: aws.String(change.ID),
+		}, rp.timing.waiterOptions(ctx, rp.clock, rp.timing.changePollTime)...)
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == request.WaiterResourceNotReadyErrorCode {
+				return errors.Wrapf(ErrChangeNotInSync, "change %s in zone %s after %s", change.ID, change.ZoneID, rp.clock.Now().Sub(start).Round(time.Second))
+			}
+
+			return errors.Wrapf(err, "failed to get change %s in zone %s", change.ID, change.ZoneID)
+		}
+	}
+
+	zerolog.Ctx(ctx).Info().Int("changes", len(changes)).Dur("propagation", rp.clock.Now().Sub(start)).Msg("changes are INSYNC")
+
+	return nil
+}
+
+// recordSet returns the record set in the hosted zone with the name and type of the validation
+// record, or nil if there isn't one
+func (rp *Route53Provider) recordSet(ctx context.Context, hostedZoneID string, record dns.Record) (*route53.ResourceRecordSet, error) {
+	name := fqdn(record.Name)
+
+	res, err := rp.route53.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(record.Type),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list records named %s in zone %s", name, hostedZoneID)
+	}
+
+	if len(res.ResourceRecordSets) == 0 || !matchesRecordSet(res.ResourceRecordSets[0], record) {
+		return nil, nil
+	}
+
+	return res.ResourceRecordSets[0], nil
+}
+
+// matchesRecordSet checks the record set has the name and type of the validation record
+func matchesRecordSet(rrs *route53.ResourceRecordSet, record dns.Record) bool {
+	return fqdn(aws.StringValue(rrs.Name)) == fqdn(record.Name) &&
+		strings.EqualFold(aws.StringValue(rrs.Type), record.Type)
+}
+
+// changeRecords submits the changes to the hosted zone returning the ids of the changes
+func (rp *Route53Provider) changeRecords(ctx context.Context, hostedZoneID string, changes []*route53.Change) ([]dns.Change, error) {
+	submitted := []dns.Change{}
+
+	for _, batch := range batchChanges(changes) {
+		res, err := rp.route53.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(hostedZoneID),
+			ChangeBatch:  &route53.ChangeBatch{Changes: batch},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to change %d records in zone %s", len(batch), hostedZoneID)
+		}
+
+		if res.ChangeInfo != nil {
+			submitted = append(submitted, dns.Change{ZoneID: hostedZoneID, ID: aws.StringValue(res.ChangeInfo.Id)})
+		}
+	}
+
+	return submitted, nil
+}
+
+// batchChanges splits the changes into batches which fit within the route53 limits on the number of
+// records and characters in a request, note UPSERT changes count twice towards these limits
+func batchChanges(changes []*route53.Change) [][]*route53.Change {
+	batches := [][]*route53.Change{}
+	batch := []*route53.Change{}
+
+	var records, chars int
+
+	for _, change := range changes {
+		changeRecords, changeChars := changeSize(change)
+
+		if len(batch) > 0 && (records+changeRecords > maxBatchRecords || chars+changeChars > maxBatchChars) {
+			batches = append(batches, batch)
+			batch = []*route53.Change{}
+			records, chars = 0, 0
+		}
+
+		batch = append(batch, change)
+		records += changeRecords
+		chars += changeChars
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+func changeSize(change *route53.Change) (records, chars int) {
+	for _, rr := range change.ResourceRecordSet.ResourceRecords {
+		records++
+		chars += len(aws.StringValue(rr.Value))
+	}
+
+	if aws.StringValue(change.Action) == route53.ChangeActionUpsert {
+		return records * 2, chars * 2
+	}
+
+	return records, chars
+}
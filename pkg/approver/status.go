@@ -0,0 +1,74 @@
+package approver
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/pkg/errors"
+)
+
+// CertificateStatus summarises the state of a certificate along with its renewal
+type CertificateStatus struct {
+	CertificateArn          string
+	DomainName              string
+	SubjectAlternativeNames []string
+	Status                  string
+	Type                    string
+	FailureReason           string
+	NotAfter                time.Time
+	InUseBy                 []string
+	Domains                 []DomainStatus
+	RenewalEligibility      string
+	RenewalStatus           string
+	RenewalStatusReason     string
+}
+
+// Managed checks the tags record the certificate was requested by the approver, either for a stack
+// or by the cli
+func Managed(tags map[string]string) bool {
+	return tags[TagStackID] != "" || tags[TagSource] != ""
+}
+
+// Eligible checks ACM will renew the certificate before it expires
+func (s *CertificateStatus) Eligible() bool {
+	return s.RenewalEligibility == acm.RenewalEligibilityEligible
+}
+
+func (ac *certificateApprover) Status(ctx context.Context, certificateArn string) (*CertificateStatus, error) {
+	res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(certificateArn),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+	}
+
+	cert := res.Certificate
+
+	status := &CertificateStatus{
+		CertificateArn:          certificateArn,
+		DomainName:              aws.StringValue(cert.DomainName),
+		SubjectAlternativeNames: aws.StringValueSlice(cert.SubjectAlternativeNames),
+		Status:                  aws.StringValue(cert.Status),
+		Type:                    aws.StringValue(cert.Type),
+		FailureReason:           aws.StringValue(cert.FailureReason),
+		NotAfter:                aws.TimeValue(cert.NotAfter),
+		InUseBy:                 aws.StringValueSlice(cert.InUseBy),
+		RenewalEligibility:      aws.StringValue(cert.RenewalEligibility),
+	}
+
+	for _, domainValidation := range cert.DomainValidationOptions {
+		status.Domains = append(status.Domains, DomainStatus{
+			DomainName:       aws.StringValue(domainValidation.DomainName),
+			ValidationStatus: aws.StringValue(domainValidation.ValidationStatus),
+		})
+	}
+
+	if cert.RenewalSummary != nil {
+		status.RenewalStatus = aws.StringValue(cert.RenewalSummary.RenewalStatus)
+		status.RenewalStatusReason = aws.StringValue(cert.RenewalSummary.RenewalStatusReason)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,163 @@
+package approver
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// tags added to certificates to record which stack and resource they belong to
+const (
+	TagStackID           = "serverless-acm-approver:stack-id"
+	TagLogicalResourceID = "serverless-acm-approver:logical-resource-id"
+	TagVersion           = "serverless-acm-approver:version"
+	// TagZones records the hosted zones selected when the certificate was requested, so the validation
+	// records can later be republished into the same zones
+	TagZones = "serverless-acm-approver:zones"
+	// TagRoute53RoleArn records the role assumed to reach the hosted zones, this is empty when the
+	// zones are in the local account
+	TagRoute53RoleArn = "serverless-acm-approver:route53-role-arn"
+	// TagSource records certificates requested outside of a stack, such as by the cli
+	TagSource = "serverless-acm-approver:source"
+)
+
+// SourceCLI the TagSource of certificates requested by the acm-approver cli
+const SourceCLI = "cli"
+
+const (
+	reservedTagPrefix = "serverless-acm-approver:"
+	awsTagPrefix      = "aws:"
+)
+
+// ACM limit on the length of a tag value
+const maxTagValueLength = 256
+
+// Version of the approver, this is set at build time
+var Version = "dev"
+
+// Tag removes the tags with the listed keys then adds or updates the supplied tags on the certificate
+func (ac *certificateApprover) Tag(ctx context.Context, certificateArn string, tags map[string]string, removeKeys []string) error {
+	ctx = ac.withCertificateLogger(ctx, certificateArn)
+
+	if len(removeKeys) > 0 {
+		zerolog.Ctx(ctx).Info().Strs("keys", removeKeys).Msg("removing certificate tags")
+
+		removeTags := []*acm.Tag{}
+
+		for _, key := range removeKeys {
+			removeTags = append(removeTags, &acm.Tag{Key: aws.String(key)})
+		}
+
+		_, err := ac.acm.RemoveTagsFromCertificateWithContext(ctx, &acm.RemoveTagsFromCertificateInput{
+			CertificateArn: aws.String(certificateArn),
+			Tags:           removeTags,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove tags from certificate %s", certificateArn)
+		}
+	}
+
+	if len(tags) > 0 {
+		zerolog.Ctx(ctx).Info().Int("tags", len(tags)).Msg("adding certificate tags")
+
+		_, err := ac.acm.AddTagsToCertificateWithContext(ctx, &acm.AddTagsToCertificateInput{
+			CertificateArn: aws.String(certificateArn),
+			Tags:           acmTags(tags),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to add tags to certificate %s", certificateArn)
+		}
+	}
+
+	return nil
+}
+
+func (ac *certificateApprover) Tags(ctx context.Context, certificateArn string) (map[string]string, error) {
+	res, err := ac.acm.ListTagsForCertificateWithContext(ctx, &acm.ListTagsForCertificateInput{
+		CertificateArn: aws.String(certificateArn),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list tags for certificate %s", certificateArn)
+	}
+
+	tags := map[string]string{}
+
+	for _, tag := range res.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return tags, nil
+}
+
+// acmTags converts the tags to acm tags sorted by key
+func acmTags(tags map[string]string) []*acm.Tag {
+	keys := make([]string, 0, len(tags))
+
+	for k := range tags {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	acmtags := []*acm.Tag{}
+
+	for _, k := range keys {
+		acmtags = append(acmtags, &acm.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+
+	return acmtags
+}
+
+// ReservedTag checks the tag key is reserved for use by AWS or the approver
+func ReservedTag(key string) bool {
+	return strings.HasPrefix(key, awsTagPrefix) || strings.HasPrefix(key, reservedTagPrefix)
+}
+
+// ZonesTag encodes the zones as the value of TagZones, the default hosted zone id comes first followed
+// by domain=zone-id entries separated by spaces, false is returned if this doesn't fit in a tag value
+func ZonesTag(zones Zones) (string, bool) {
+	entries := []string{}
+
+	if zones.HostedZoneID != "" {
+		entries = append(entries, zones.HostedZoneID)
+	}
+
+	domains := make([]string, 0, len(zones.Domains))
+
+	for domain := range zones.Domains {
+		domains = append(domains, domain)
+	}
+
+	sort.Strings(domains)
+
+	for _, domain := range domains {
+		// wildcards use the same zone as the name they cover, and * isn't allowed in tag values
+		entries = append(entries, strings.TrimPrefix(domain, "*.")+"="+zones.Domains[domain])
+	}
+
+	value := strings.Join(entries, " ")
+
+	return value, len(value) <= maxTagValueLength
+}
+
+// ParseZonesTag decodes the zones recorded by ZonesTag, an empty value means the zones were discovered
+func ParseZonesTag(value string) Zones {
+	zones := Zones{Domains: map[string]string{}}
+
+	for _, entry := range strings.Fields(value) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 1 {
+			zones.HostedZoneID = parts[0]
+			continue
+		}
+
+		zones.Domains[parts[0]] = parts[1]
+	}
+
+	return zones
+}
@@ -0,0 +1,135 @@
+package approver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/api/key"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
+)
+
+// tracedCertificate wraps each certificate method in a span
+type tracedCertificate struct {
+	next Certificate
+}
+
+func withTracing(next Certificate) Certificate {
+	return &tracedCertificate{next: next}
+}
+
+func (tc *tracedCertificate) Approve(ctx context.Context, certificateArn string, zones Zones) (err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Approve", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Approve(ctx, certificateArn, zones)
+}
+
+func (tc *tracedCertificate) Publish(ctx context.Context, certificateArn string, zones Zones) (err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Publish", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Publish(ctx, certificateArn, zones)
+}
+
+func (tc *tracedCertificate) WaitForValidation(ctx context.Context, certificateArn string) (err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.WaitForValidation", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.WaitForValidation(ctx, certificateArn)
+}
+
+func (tc *tracedCertificate) Preflight(ctx context.Context, domainName string, subjectAlternativeNames []string, zones Zones) (err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Preflight", key.String("certificate.domain_name", domainName))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Preflight(ctx, domainName, subjectAlternativeNames, zones)
+}
+
+func (tc *tracedCertificate) Request(ctx context.Context, requestID string, domainName string, subjectAlternativeNames []string, tags map[string]string) (certificateArn string, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Request", key.String("certificate.domain_name", domainName))
+	defer func() {
+		span.SetAttributes(key.String("certificate.arn", certificateArn))
+		tracing.End(ctx, span, err)
+	}()
+
+	return tc.next.Request(ctx, requestID, domainName, subjectAlternativeNames, tags)
+}
+
+func (tc *tracedCertificate) FindIssued(ctx context.Context, domainName string, subjectAlternativeNames []string) (certificateArn string, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.FindIssued", key.String("certificate.domain_name", domainName))
+	defer func() {
+		span.SetAttributes(key.String("certificate.arn", certificateArn))
+		tracing.End(ctx, span, err)
+	}()
+
+	return tc.next.FindIssued(ctx, domainName, subjectAlternativeNames)
+}
+
+func (tc *tracedCertificate) Status(ctx context.Context, certificateArn string) (status *CertificateStatus, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Status", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Status(ctx, certificateArn)
+}
+
+func (tc *tracedCertificate) Tags(ctx context.Context, certificateArn string) (tags map[string]string, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Tags", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Tags(ctx, certificateArn)
+}
+
+func (tc *tracedCertificate) Tag(ctx context.Context, certificateArn string, tags map[string]string, removeKeys []string) (err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Tag", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Tag(ctx, certificateArn, tags, removeKeys)
+}
+
+func (tc *tracedCertificate) ListManaged(ctx context.Context) (certificateArns []string, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.ListManaged")
+	defer func() {
+		span.SetAttributes(key.Int("certificate.count", len(certificateArns)))
+		tracing.End(ctx, span, err)
+	}()
+
+	return tc.next.ListManaged(ctx)
+}
+
+func (tc *tracedCertificate) Repair(ctx context.Context, certificateArn string, zones Zones) (repairs []RecordRepair, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Repair", key.String("certificate.arn", certificateArn))
+	defer func() {
+		span.SetAttributes(key.Int("certificate.repairs", len(repairs)))
+		tracing.End(ctx, span, err)
+	}()
+
+	return tc.next.Repair(ctx, certificateArn, zones)
+}
+
+func (tc *tracedCertificate) PlanRequest(ctx context.Context, domainName string, subjectAlternativeNames []string, zones Zones) (plan *Plan, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.PlanRequest", key.String("certificate.domain_name", domainName))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.PlanRequest(ctx, domainName, subjectAlternativeNames, zones)
+}
+
+func (tc *tracedCertificate) PlanApprove(ctx context.Context, certificateArn string, zones Zones) (plan *Plan, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.PlanApprove", key.String("certificate.arn", certificateArn))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.PlanApprove(ctx, certificateArn, zones)
+}
+
+func (tc *tracedCertificate) PlanDelete(ctx context.Context, certificateArn string, zones Zones, policy InUsePolicy) (plan *Plan, err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.PlanDelete", key.String("certificate.arn", certificateArn), key.String("certificate.in_use_policy", string(policy)))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.PlanDelete(ctx, certificateArn, zones, policy)
+}
+
+func (tc *tracedCertificate) Delete(ctx context.Context, certificateArn string, zones Zones, policy InUsePolicy) (err error) {
+	ctx, span := tracing.Start(ctx, "Certificate.Delete", key.String("certificate.arn", certificateArn), key.String("certificate.in_use_policy", string(policy)))
+	defer func() { tracing.End(ctx, span, err) }()
+
+	return tc.next.Delete(ctx, certificateArn, zones, policy)
+}
@@ -0,0 +1,106 @@
+package approver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// ErrValidationPending returned when the certificate is still pending validation after the maximum
+// attempts, it belongs to the ErrTimeout class
+var ErrValidationPending error = &classifiedError{msg: "certificate is still pending validation", class: ErrTimeout}
+
+// DomainStatus the validation status of a domain on the certificate
+type DomainStatus struct {
+	DomainName       string
+	ValidationStatus string
+}
+
+// ValidationError returned when ACM stops validating a certificate without issuing it, for example
+// with a FailureReason of CAA_ERROR or DOMAIN_NOT_ALLOWED
+type ValidationError struct {
+	CertificateArn string
+	Status         string
+	FailureReason  string
+	Domains        []DomainStatus
+}
+
+func (e *ValidationError) Error() string {
+	domains := make([]string, 0, len(e.Domains))
+
+	for _, d := range e.Domains {
+		domains = append(domains, fmt.Sprintf("%s %s", d.DomainName, d.ValidationStatus))
+	}
+
+	msg := fmt.Sprintf("certificate %s is %s", e.CertificateArn, e.Status)
+
+	if e.FailureReason != "" {
+		msg += " with reason " + e.FailureReason
+	}
+
+	if len(domains) > 0 {
+		msg += " (" + strings.Join(domains, ", ") + ")"
+	}
+
+	return msg
+}
+
+// waitForIssued polls the status of the certificate until it is issued, returning a ValidationError
+// as soon as it reaches a state which it can't be issued from
+func (ac *certificateApprover) waitForIssued(ctx context.Context, certificateArn string) error {
+	for i := 1; ; i++ {
+		res, err := ac.acm.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{
+			CertificateArn: aws.String(certificateArn),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to describe certificate %s", certificateArn)
+		}
+
+		status := aws.StringValue(res.Certificate.Status)
+
+		switch status {
+		case acm.CertificateStatusIssued:
+			zerolog.Ctx(ctx).Info().Int("attempts", i).Msg("certificate issued")
+			ac.recordRetries("WaitForValidation", i)
+			ac.recordTimeToIssued(res.Certificate)
+			return nil
+		case acm.CertificateStatusFailed, acm.CertificateStatusValidationTimedOut, acm.CertificateStatusRevoked,
+			acm.CertificateStatusExpired, acm.CertificateStatusInactive:
+			ac.recordRetries("WaitForValidation", i)
+			return newValidationError(certificateArn, res.Certificate)
+		}
+
+		if i >= ac.timing.maxAttempts {
+			return errors.Wrapf(ErrValidationPending, "certificate %s after %d attempts", certificateArn, i)
+		}
+
+		zerolog.Ctx(ctx).Info().Str("status", status).Int("attempt", i).Msg("certificate not yet issued")
+
+		err = ac.clock.Sleep(ctx, ac.timing.pollDelay(ac.timing.validationPollTime, i))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newValidationError(certificateArn string, cert *acm.CertificateDetail) *ValidationError {
+	validationErr := &ValidationError{
+		CertificateArn: certificateArn,
+		Status:         aws.StringValue(cert.Status),
+		FailureReason:  aws.StringValue(cert.FailureReason),
+	}
+
+	for _, domainValidation := range cert.DomainValidationOptions {
+		validationErr.Domains = append(validationErr.Domains, DomainStatus{
+			DomainName:       aws.StringValue(domainValidation.DomainName),
+			ValidationStatus: aws.StringValue(domainValidation.ValidationStatus),
+		})
+	}
+
+	return validationErr
+}
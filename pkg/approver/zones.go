@@ -0,0 +1,200 @@
+package approver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// zoneResolver locates the hosted zone used to publish each validation record, it caches the public
+// zones listed for each zone name so certificates with many names don't repeatedly list the same zones
+type zoneResolver struct {
+	route53 route53iface.Route53API
+	zones   Zones
+	domains map[string]string
+	cache   map[string][]string
+	// preferredZoneName the name of the preferred hosted zone, this is looked up on first use
+	preferredZoneName string
+}
+
+func newZoneResolver(route53api route53iface.Route53API, zones Zones) *zoneResolver {
+	domains := map[string]string{}
+
+	for domain, zoneID := range zones.Domains {
+		domains[fqdn(strings.TrimPrefix(domain, "*."))] = trimHostedZoneID(zoneID)
+	}
+
+	return &zoneResolver{
+		route53: route53api,
+		zones:   zones,
+		domains: domains,
+		cache:   map[string][]string{},
+	}
+}
+
+// Resolve returns the hosted zone for the supplied record name, the longest domain mapping which
+// matches the name is used first, then the default hosted zone id, then the preferred hosted zone
+// for names within it, otherwise the public hosted zone with the longest matching suffix is discovered
+func (zr *zoneResolver) Resolve(ctx context.Context, recordName string) (string, error) {
+	recordName = fqdn(recordName)
+
+	for _, name := range parentNames(recordName) {
+		if zoneID, ok := zr.domains[name]; ok {
+			return zoneID, nil
+		}
+	}
+
+	if zr.zones.HostedZoneID != "" {
+		return zr.zones.HostedZoneID, nil
+	}
+
+	if zr.zones.PreferredHostedZoneID != "" {
+		preferred, err := zr.inPreferredZone(ctx, recordName)
+		if err != nil {
+			return "", err
+		}
+
+		if preferred {
+			return trimHostedZoneID(zr.zones.PreferredHostedZoneID), nil
+		}
+	}
+
+	return zr.discover(ctx, recordName)
+}
+
+// inPreferredZone checks the record name is within the preferred hosted zone
+func (zr *zoneResolver) inPreferredZone(ctx context.Context, recordName string) (bool, error) {
+	if zr.preferredZoneName == "" {
+		hostedZoneID := trimHostedZoneID(zr.zones.PreferredHostedZoneID)
+
+		res, err := zr.route53.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{
+			Id: aws.String(hostedZoneID),
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get hosted zone %s", hostedZoneID)
+		}
+
+		zr.preferredZoneName = fqdn(aws.StringValue(res.HostedZone.Name))
+	}
+
+	return inZone(recordName, zr.preferredZoneName), nil
+}
+
+func (zr *zoneResolver) discover(ctx context.Context, recordName string) (string, error) {
+	candidates := parentNames(recordName)
+
+	// the leading label of a validation record is the ACM token which is never a zone
+	if strings.HasPrefix(recordName, "_") {
+		candidates = candidates[1:]
+	}
+
+	// walk up the labels of the record name, the first name with a public hosted zone is the longest match
+	for _, candidate := range candidates {
+		zoneIDs, err := zr.listPublicZones(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+
+		switch len(zoneIDs) {
+		case 0:
+			continue
+		case 1:
+			zerolog.Ctx(ctx).Info().Str("record", recordName).Str("zone", candidate).Str("hostedZoneId", zoneIDs[0]).Msg("discovered hosted zone")
+			return zoneIDs[0], nil
+		default:
+			return "", invalidInputf("found %d public hosted zones named %s for record %s, HostedZoneId is required to select one",
+				len(zoneIDs), candidate, recordName)
+		}
+	}
+
+	return "", invalidInputf("no public hosted zone found for record %s, HostedZoneId is required", recordName)
+}
+
+// listPublicZones returns the ids of the public hosted zones named exactly zoneName, the result is
+// cached as the names on a certificate usually share zones
+func (zr *zoneResolver) listPublicZones(ctx context.Context, zoneName string) ([]string, error) {
+	if zoneIDs, ok := zr.cache[zoneName]; ok {
+		return zoneIDs, nil
+	}
+
+	zoneIDs, err := zr.listZonesByName(ctx, zoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	zr.cache[zoneName] = zoneIDs
+
+	return zoneIDs, nil
+}
+
+func (zr *zoneResolver) listZonesByName(ctx context.Context, zoneName string) ([]string, error) {
+	zoneIDs := []string{}
+
+	input := &route53.ListHostedZonesByNameInput{
+		DNSName: aws.String(zoneName),
+	}
+
+	for {
+		res, err := zr.route53.ListHostedZonesByNameWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list hosted zones named %s", zoneName)
+		}
+
+		for _, zone := range res.HostedZones {
+			// results are sorted by name so once we pass the name we are done
+			if !strings.EqualFold(aws.StringValue(zone.Name), zoneName) {
+				return zoneIDs, nil
+			}
+
+			if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+				continue
+			}
+
+			zoneIDs = append(zoneIDs, trimHostedZoneID(aws.StringValue(zone.Id)))
+		}
+
+		if !aws.BoolValue(res.IsTruncated) {
+			return zoneIDs, nil
+		}
+
+		input.DNSName = res.NextDNSName
+		input.HostedZoneId = res.NextHostedZoneId
+	}
+}
+
+// parentNames returns the name and each parent name, excluding the root, longest first
+func parentNames(name string) []string {
+	name = fqdn(name)
+
+	names := []string{}
+
+	for name != "." && name != "" {
+		names = append(names, name)
+
+		idx := strings.Index(name, ".")
+		name = name[idx+1:]
+	}
+
+	return names
+}
+
+// fqdn lower cases the name and ensures it has a trailing dot as returned by route53
+func fqdn(name string) string {
+	name = strings.ToLower(name)
+
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	return name
+}
+
+// trimHostedZoneID strips the /hostedzone/ prefix returned in hosted zone ids by the route53 API
+func trimHostedZoneID(zoneID string) string {
+	return strings.TrimPrefix(zoneID, "/hostedzone/")
+}
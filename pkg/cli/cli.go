@@ -0,0 +1,414 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+const usage = `usage: acm-approver <command> [flags]
+
+commands:
+  request   request a certificate, optionally approving it
+  approve   publish the validation records for a certificate and wait for it to be issued
+  delete    delete a certificate along with its validation records
+  status    describe a certificate
+  list      list the certificates managed by the approver
+
+run acm-approver <command> -h for the flags of each command
+`
+
+// ErrUsage returned when the command line is invalid, the usage has already been written
+var ErrUsage = errors.New("invalid usage")
+
+// CLI runs approver commands outside of cloudformation
+type CLI struct {
+	stdout      io.Writer
+	stderr      io.Writer
+	newApprover func(opts ...approver.Option) approver.Certificate
+}
+
+// New creates a CLI which writes results to stdout and usage to stderr
+func New(stdout, stderr io.Writer) *CLI {
+	return &CLI{stdout: stdout, stderr: stderr, newApprover: approver.New}
+}
+
+// Run runs the command named by the first argument
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(c.stderr, usage)
+		return ErrUsage
+	}
+
+	commands := map[string]func(context.Context, []string) error{
+		"request": c.request,
+		"approve": c.approve,
+		"delete":  c.delete,
+		"status":  c.status,
+		"list":    c.list,
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.stderr, "unknown command %q\n\n%s", args[0], usage)
+		return ErrUsage
+	}
+
+	return command(ctx, args[1:])
+}
+
+func (c *CLI) request(ctx context.Context, args []string) error {
+	fs, common := c.flagSet("request")
+
+	domainName := fs.String("domain-name", "", "domain name of the certificate (required)")
+	requestID := fs.String("request-id", "", "idempotency token, requests with the same id return the same certificate (default: generated)")
+	approve := fs.Bool("approve", false, "publish the validation records and wait for the certificate to be issued")
+	skipPreflight := fs.Bool("skip-preflight", false, "skip the hosted zone and CAA checks before requesting the certificate")
+	plan := fs.Bool("plan", false, "print the calls which would be made without making them")
+
+	sans := stringsFlag{}
+	fs.Var(&sans, "san", "subject alternative name, may be repeated")
+
+	tags := mapFlag{}
+	fs.Var(&tags, "tag", "tag added to the certificate as key=value, may be repeated")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *domainName == "" {
+		return c.usageError(fs, "missing required -domain-name")
+	}
+
+	for _, k := range sortedKeys(tags) {
+		if approver.ReservedTag(k) {
+			return c.usageError(fs, fmt.Sprintf("-tag %s must not use a key starting with aws: or serverless-acm-approver:", k))
+		}
+	}
+
+	if *requestID == "" {
+		*requestID = fmt.Sprintf("acm-approver-%s-%d", *domainName, time.Now().UnixNano())
+	}
+
+	certApprover := c.newApprover(common.options()...)
+
+	if *plan {
+		return c.printPlan(certApprover.PlanRequest(ctx, *domainName, sans, common.zones()))
+	}
+
+	if !*skipPreflight {
+		err := certApprover.Preflight(ctx, *domainName, sans, common.zones())
+		if err != nil {
+			return err
+		}
+	}
+
+	certificateTags := map[string]string{}
+
+	for k, v := range tags {
+		certificateTags[k] = v
+	}
+
+	// the source marks the certificate as managed so it is listed, repaired and renewed by the watchdog
+	certificateTags[approver.TagSource] = approver.SourceCLI
+	certificateTags[approver.TagVersion] = approver.Version
+
+	if zones, ok := approver.ZonesTag(common.zones()); ok {
+		certificateTags[approver.TagZones] = zones
+	}
+
+	certificateTags[approver.TagRoute53RoleArn] = common.route53RoleArn
+
+	certificateArn, err := certApprover.Request(ctx, *requestID, *domainName, sans, certificateTags)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, certificateArn)
+
+	if !*approve {
+		return nil
+	}
+
+	return certApprover.Approve(ctx, certificateArn, common.zones())
+}
+
+func (c *CLI) approve(ctx context.Context, args []string) error {
+	fs, common := c.flagSet("approve")
+
+	certificateArn := fs.String("arn", "", "arn of the certificate (required)")
+	publishOnly := fs.Bool("publish-only", false, "publish the validation records without waiting for the certificate to be issued")
+	plan := fs.Bool("plan", false, "print the records which would be published without publishing them")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *certificateArn == "" {
+		return c.usageError(fs, "missing required -arn")
+	}
+
+	certApprover := c.newApprover(common.options()...)
+
+	if *plan {
+		return c.printPlan(certApprover.PlanApprove(ctx, *certificateArn, common.zones()))
+	}
+
+	if *publishOnly {
+		return certApprover.Publish(ctx, *certificateArn, common.zones())
+	}
+
+	return certApprover.Approve(ctx, *certificateArn, common.zones())
+}
+
+func (c *CLI) delete(ctx context.Context, args []string) error {
+	fs, common := c.flagSet("delete")
+
+	certificateArn := fs.String("arn", "", "arn of the certificate (required)")
+	inUsePolicy := fs.String("in-use-policy", string(approver.InUseFail), "what to do when the certificate is in use, one of Fail, Retain or Wait")
+	plan := fs.Bool("plan", false, "print the calls which would be made without making them")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *certificateArn == "" {
+		return c.usageError(fs, "missing required -arn")
+	}
+
+	policy := approver.InUsePolicy(*inUsePolicy)
+
+	switch policy {
+	case approver.InUseFail, approver.InUseRetain, approver.InUseWait:
+	default:
+		return c.usageError(fs, "-in-use-policy must be one of Fail, Retain or Wait")
+	}
+
+	certApprover := c.newApprover(common.options()...)
+
+	if *plan {
+		return c.printPlan(certApprover.PlanDelete(ctx, *certificateArn, common.zones(), policy))
+	}
+
+	return certApprover.Delete(ctx, *certificateArn, common.zones(), policy)
+}
+
+func (c *CLI) status(ctx context.Context, args []string) error {
+	fs, common := c.flagSet("status")
+
+	certificateArn := fs.String("arn", "", "arn of the certificate (required)")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	if *certificateArn == "" {
+		return c.usageError(fs, "missing required -arn")
+	}
+
+	certApprover := c.newApprover(common.options()...)
+
+	status, err := certApprover.Status(ctx, *certificateArn)
+	if err != nil {
+		return err
+	}
+
+	tags, err := certApprover.Tags(ctx, *certificateArn)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Arn:\t%s\n", status.CertificateArn)
+	fmt.Fprintf(w, "DomainName:\t%s\n", status.DomainName)
+	fmt.Fprintf(w, "SubjectAlternativeNames:\t%s\n", strings.Join(status.SubjectAlternativeNames, ", "))
+	fmt.Fprintf(w, "Status:\t%s\n", status.Status)
+	fmt.Fprintf(w, "Type:\t%s\n", status.Type)
+	fmt.Fprintf(w, "Managed:\t%t\n", approver.Managed(tags))
+
+	if status.FailureReason != "" {
+		fmt.Fprintf(w, "FailureReason:\t%s\n", status.FailureReason)
+	}
+
+	if !status.NotAfter.IsZero() {
+		fmt.Fprintf(w, "NotAfter:\t%s\n", status.NotAfter.Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(w, "InUseBy:\t%s\n", strings.Join(status.InUseBy, ", "))
+	fmt.Fprintf(w, "RenewalEligibility:\t%s\n", status.RenewalEligibility)
+
+	if status.RenewalStatus != "" {
+		fmt.Fprintf(w, "RenewalStatus:\t%s %s\n", status.RenewalStatus, status.RenewalStatusReason)
+	}
+
+	for _, domain := range status.Domains {
+		fmt.Fprintf(w, "Validation:\t%s %s\n", domain.DomainName, domain.ValidationStatus)
+	}
+
+	for _, k := range sortedKeys(tags) {
+		fmt.Fprintf(w, "Tag:\t%s=%s\n", k, tags[k])
+	}
+
+	return w.Flush()
+}
+
+func (c *CLI) list(ctx context.Context, args []string) error {
+	fs, common := c.flagSet("list")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	certApprover := c.newApprover(common.options()...)
+
+	certificateArns, err := certApprover.ListManaged(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ARN\tDOMAIN NAME\tSTATUS\tRENEWAL")
+
+	for _, certificateArn := range certificateArns {
+		status, err := certApprover.Status(ctx, certificateArn)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", certificateArn, status.DomainName, status.Status, status.RenewalEligibility)
+	}
+
+	return w.Flush()
+}
+
+func (c *CLI) printPlan(plan *approver.Plan, err error) error {
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, plan.String())
+
+	return nil
+}
+
+// commonFlags select the region, hosted zones and credentials used by every command
+type commonFlags struct {
+	region            string
+	hostedZoneID      string
+	hostedZones       mapFlag
+	route53RoleArn    string
+	route53ExternalID string
+}
+
+func (c *CLI) flagSet(name string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+
+	common := &commonFlags{hostedZones: mapFlag{}}
+
+	fs.StringVar(&common.region, "region", "", "region of the certificate (default: from the environment)")
+	fs.StringVar(&common.hostedZoneID, "hosted-zone-id", "", "hosted zone for the validation records (default: discovered)")
+	fs.Var(&common.hostedZones, "hosted-zone", "hosted zone for a domain as domain=zone-id, may be repeated")
+	fs.StringVar(&common.route53RoleArn, "route53-role-arn", "", "role assumed to update route53")
+	fs.StringVar(&common.route53ExternalID, "route53-external-id", "", "external id used when assuming the route53 role")
+
+	return fs, common
+}
+
+func (cf *commonFlags) options() []approver.Option {
+	opts := []approver.Option{}
+
+	if cf.region != "" {
+		opts = append(opts, approver.WithConfig(aws.NewConfig().WithRegion(cf.region)))
+	}
+
+	if cf.route53RoleArn != "" {
+		opts = append(opts, approver.WithRoute53Role(cf.route53RoleArn, cf.route53ExternalID))
+	}
+
+	return opts
+}
+
+func (cf *commonFlags) zones() approver.Zones {
+	return approver.Zones{HostedZoneID: cf.hostedZoneID, Domains: cf.hostedZones}
+}
+
+func (c *CLI) parse(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		return ErrUsage
+	}
+
+	if fs.NArg() > 0 {
+		return c.usageError(fs, fmt.Sprintf("unexpected arguments %v", fs.Args()))
+	}
+
+	return nil
+}
+
+func (c *CLI) usageError(fs *flag.FlagSet, msg string) error {
+	fmt.Fprintf(c.stderr, "%s\n", msg)
+	fs.Usage()
+
+	return ErrUsage
+}
+
+// stringsFlag collects the values of a repeated flag
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// mapFlag collects the key=value pairs of a repeated flag
+type mapFlag map[string]string
+
+func (m mapFlag) String() string {
+	pairs := []string{}
+
+	for _, k := range sortedKeys(m) {
+		pairs = append(pairs, k+"="+m[k])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (m mapFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("%q must be in the form key=value", value)
+	}
+
+	m[parts[0]] = parts[1]
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wolfeidau/serverless-acm-approver/mocks"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+func newTestCLI(cert approver.Certificate) (*CLI, *bytes.Buffer, *bytes.Buffer) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+	return &CLI{
+		stdout: stdout,
+		stderr: stderr,
+		newApprover: func(opts ...approver.Option) approver.Certificate {
+			return cert
+		},
+	}, stdout, stderr
+}
+
+func TestRun_Usage(t *testing.T) {
+	assert := require.New(t)
+
+	c, _, stderr := newTestCLI(nil)
+
+	err := c.Run(context.TODO(), []string{})
+	assert.Equal(ErrUsage, err)
+	assert.Contains(stderr.String(), "usage: acm-approver <command>")
+
+	err = c.Run(context.TODO(), []string{"renew"})
+	assert.Equal(ErrUsage, err)
+	assert.Contains(stderr.String(), `unknown command "renew"`)
+
+	err = c.Run(context.TODO(), []string{"status"})
+	assert.Equal(ErrUsage, err)
+	assert.Contains(stderr.String(), "missing required -arn")
+}
+
+func TestRun_Request(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	zones := approver.Zones{HostedZoneID: "Z123", Domains: map[string]string{"t.2.co": "Z456"}}
+
+	gomock.InOrder(
+		cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{"t.2.co"}, zones).Return(nil),
+		cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{"t.2.co"},
+			map[string]string{
+				approver.TagSource: approver.SourceCLI, approver.TagVersion: approver.Version,
+				approver.TagZones: "Z123 t.2.co=Z456", approver.TagRoute53RoleArn: "", "Team": "platform",
+			}).Return("ghi789", nil),
+		cert.EXPECT().Approve(gomock.Any(), "ghi789", zones).Return(nil),
+	)
+
+	c, stdout, _ := newTestCLI(cert)
+
+	err := c.Run(context.TODO(), []string{"request", "-domain-name", "t.1.co", "-san", "t.2.co", "-request-id", "abc123",
+		"-tag", "Team=platform", "-hosted-zone-id", "Z123", "-hosted-zone", "t.2.co=Z456", "-approve"})
+	assert.NoError(err)
+	assert.Equal("ghi789\n", stdout.String())
+}
+
+func TestRun_RequestReservedTag(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, key := range []string{"aws:cloudformation:stack-id", approver.TagStackID} {
+		c, _, stderr := newTestCLI(mocks.NewMockCertificate(ctrl))
+
+		err := c.Run(context.TODO(), []string{"request", "-domain-name", "t.1.co", "-tag", key + "=abc"})
+		assert.Equal(ErrUsage, err)
+		assert.Contains(stderr.String(), "-tag "+key+" must not use a key starting with aws: or serverless-acm-approver:")
+	}
+}
+
+func TestRun_Delete(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Delete(gomock.Any(), "ghi789", approver.Zones{Domains: map[string]string{}}, approver.InUseRetain).Return(nil)
+
+	c, _, _ := newTestCLI(cert)
+
+	err := c.Run(context.TODO(), []string{"delete", "-arn", "ghi789", "-in-use-policy", "Retain"})
+	assert.NoError(err)
+
+	err = c.Run(context.TODO(), []string{"delete", "-arn", "ghi789", "-in-use-policy", "Keep"})
+	assert.Equal(ErrUsage, err)
+}
+
+func TestRun_List(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().ListManaged(gomock.Any()).Return([]string{"ghi789"}, nil)
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{
+		CertificateArn: "ghi789", DomainName: "t.1.co", Status: "ISSUED", RenewalEligibility: "ELIGIBLE",
+	}, nil)
+
+	c, stdout, _ := newTestCLI(cert)
+
+	err := c.Run(context.TODO(), []string{"list"})
+	assert.NoError(err)
+	assert.Equal("ARN     DOMAIN NAME  STATUS  RENEWAL\nghi789  t.1.co       ISSUED  ELIGIBLE\n", stdout.String())
+}
+
+func TestRun_DeletePlan(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().PlanDelete(gomock.Any(), "ghi789", gomock.Any(), approver.InUseFail).Return(&approver.Plan{
+		Calls: []approver.PlannedCall{{Service: "acm", Operation: "DeleteCertificate", Target: "ghi789"}},
+	}, nil)
+
+	c, stdout, _ := newTestCLI(cert)
+
+	err := c.Run(context.TODO(), []string{"delete", "-arn", "ghi789", "-plan"})
+	assert.NoError(err)
+	assert.Equal("acm DeleteCertificate ghi789\n", stdout.String())
+}
@@ -0,0 +1,48 @@
+// Package dns defines how the approver publishes ACM DNS validation records, route53 is the default
+// Provider but records can be managed in any DNS service which ACM can query
+package dns
+
+import "context"
+
+// actions applied to the records in a zone
+const (
+	ActionUpsert = "UPSERT"
+	ActionDelete = "DELETE"
+)
+
+// Record a DNS validation record generated by ACM
+type Record struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// Change a submitted change to a zone, providers which apply changes immediately may return none
+type Change struct {
+	ZoneID string
+	ID     string
+}
+
+// Operation an API call made by a provider, this is used to describe planned changes
+type Operation struct {
+	Service string
+	Name    string
+}
+
+// Provider publishes and removes validation records in the zones of a DNS service
+type Provider interface {
+	// Zone returns the id of the zone which holds the record name
+	Zone(ctx context.Context, recordName string) (string, error)
+	// Lookup returns the values of the record set in the zone with the name and type of the record,
+	// or nil if there isn't one
+	Lookup(ctx context.Context, zoneID string, record Record) ([]string, error)
+	// Upsert creates or replaces the records in the zone
+	Upsert(ctx context.Context, zoneID string, records []Record) ([]Change, error)
+	// Delete removes the records from the zone, records which don't exist are skipped
+	Delete(ctx context.Context, zoneID string, records []Record) ([]Change, error)
+	// Wait waits for the changes to propagate to the servers of the DNS service, ACM can't validate
+	// the certificate until this has happened
+	Wait(ctx context.Context, changes []Change) error
+	// Operation returns the API call which applies the action, ActionUpsert or ActionDelete, to a zone
+	Operation(action string) Operation
+}
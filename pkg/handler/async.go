@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultAsyncTimeout = 30 * time.Minute
+
+	// cloudformation waits at most an hour for a custom resource to respond, the last invocation may
+	// run for up to the 15 minute lambda limit after the timeout so this leaves a margin
+	maxAsyncTimeout = 45 * time.Minute
+)
+
+// Invoker invokes a lambda function, this is used to continue asynchronous validation
+type Invoker interface {
+	InvokeWithContext(ctx aws.Context, input *lambda.InvokeInput, opts ...request.Option) (*lambda.InvokeOutput, error)
+}
+
+// AsyncState tracks the progress of an asynchronous certificate request between invocations
+type AsyncState struct {
+	CertificateArn string
+	StartedAt      time.Time
+	Invocations    int
+}
+
+// AsyncEvent is the payload the approver sends itself to continue polling a certificate, the
+// original cloudformation event is retained so the response can be sent once validation completes
+type AsyncEvent struct {
+	Event cfn.Event
+	State *AsyncState `json:"ApproverState"`
+}
+
+// Handle is the lambda entry point, it processes cloudformation custom resource events along with
+// the events the approver sends itself while validating certificates asynchronously
+func (ds *Dispatcher) Handle(ctx context.Context, payload json.RawMessage) error {
+	asyncEvent := new(AsyncEvent)
+
+	err := json.Unmarshal(payload, asyncEvent)
+	if err != nil {
+		return err
+	}
+
+	if asyncEvent.State != nil {
+		return ds.continueAsync(ctx, asyncEvent)
+	}
+
+	event := cfn.Event{}
+
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return err
+	}
+
+	if isAsync(event) {
+		return ds.startAsync(ctx, event)
+	}
+
+	_, err = cfn.LambdaWrap(ds.CreateAndApproveACMCertificate)(ctx, event)
+
+	return err
+}
+
+// isAsync checks if the event is a create or update using asynchronous validation, events with
+// invalid properties are left to the synchronous handler to report
+func isAsync(event cfn.Event) bool {
+	if event.RequestType != cfn.RequestCreate && event.RequestType != cfn.RequestUpdate {
+		return false
+	}
+
+	// in place updates only tag the existing certificate so there is nothing to wait for
+	if isInPlaceUpdate(event) {
+		return false
+	}
+
+	params := new(Params)
+
+	err := decodeProperties(withTuningEnv(event.ResourceProperties), params)
+	if err != nil {
+		return false
+	}
+
+	// plans are quick to produce so dry runs are always synchronous
+	return params.Async && !params.DryRun
+}
+
+// startAsync requests the certificate and publishes the validation records, then hands over to
+// another invocation to wait for validation, cloudformation is only sent a response on failure or
+// when an existing certificate is reused
+func (ds *Dispatcher) startAsync(ctx context.Context, event cfn.Event) error {
+	ctx = ds.eventContext(ctx, event)
+
+	start := time.Now()
+
+	params, certApprover, err := ds.prepare(event)
+	if err != nil {
+		return ds.respond(ctx, event, start, event.PhysicalResourceID, err)
+	}
+
+	reservedCtx, cancel := withResponseReserve(ctx)
+	defer cancel()
+
+	existingARN, err := findExisting(reservedCtx, certApprover, params)
+	if err != nil {
+		return ds.respond(ctx, event, start, "", describeError(reservedCtx, err))
+	}
+
+	if existingARN != "" {
+		return ds.respond(ctx, event, start, existingARN, nil)
+	}
+
+	err = certApprover.Preflight(reservedCtx, params.DomainName, params.SubjectAlternativeNames, params.Zones())
+	if err != nil {
+		return ds.respond(ctx, event, start, "", describeError(reservedCtx, err))
+	}
+
+	certificateARN, err := certApprover.Request(reservedCtx, event.RequestID, params.DomainName, params.SubjectAlternativeNames, certificateTags(event, params))
+	if err != nil {
+		return ds.respond(ctx, event, start, "", describeError(reservedCtx, err))
+	}
+
+	err = certApprover.Publish(reservedCtx, certificateARN, params.Zones())
+	if err != nil {
+		return ds.respond(ctx, event, start, certificateARN, describeError(reservedCtx, err))
+	}
+
+	return ds.reinvoke(ctx, &AsyncEvent{
+		Event: event,
+		State: &AsyncState{
+			CertificateArn: certificateARN,
+			StartedAt:      start,
+		},
+	})
+}
+
+// continueAsync waits for the certificate to be validated for as long as this invocation allows,
+// responding to cloudformation once it is issued or fails, otherwise invoking again to keep waiting
+func (ds *Dispatcher) continueAsync(ctx context.Context, asyncEvent *AsyncEvent) error {
+	event, state := asyncEvent.Event, asyncEvent.State
+
+	ctx = ds.eventContext(ctx, event)
+
+	params, certApprover, err := ds.prepare(event)
+	if err != nil {
+		return ds.respond(ctx, event, state.StartedAt, state.CertificateArn, err)
+	}
+
+	zerolog.Ctx(ctx).Info().Str("certificateArn", state.CertificateArn).Int("invocations", state.Invocations).
+		Dur("elapsed", time.Since(state.StartedAt)).Msg("continuing asynchronous validation")
+
+	reservedCtx, cancel := withResponseReserve(ctx)
+	defer cancel()
+
+	err = certApprover.WaitForValidation(reservedCtx, state.CertificateArn)
+	if err == nil || !isRetryable(err) {
+		return ds.respond(ctx, event, state.StartedAt, state.CertificateArn, err)
+	}
+
+	if elapsed := time.Since(state.StartedAt); elapsed > params.asyncTimeout() {
+		return ds.respond(ctx, event, state.StartedAt, state.CertificateArn,
+			fmt.Errorf("certificate %s was not issued within %s: %w", state.CertificateArn, elapsed.Round(time.Second), err))
+	}
+
+	return ds.reinvoke(ctx, asyncEvent)
+}
+
+// reinvoke asynchronously invokes this function again to continue waiting for validation
+func (ds *Dispatcher) reinvoke(ctx context.Context, asyncEvent *AsyncEvent) error {
+	asyncEvent.State.Invocations++
+
+	payload, err := json.Marshal(asyncEvent)
+	if err != nil {
+		return ds.respond(ctx, asyncEvent.Event, asyncEvent.State.StartedAt, asyncEvent.State.CertificateArn, err)
+	}
+
+	_, err = ds.invoker.InvokeWithContext(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(ds.functionName),
+		InvocationType: aws.String(lambda.InvocationTypeEvent),
+		Payload:        payload,
+	})
+	if err != nil {
+		return ds.respond(ctx, asyncEvent.Event, asyncEvent.State.StartedAt, asyncEvent.State.CertificateArn,
+			fmt.Errorf("failed to invoke %s to continue validation: %w", ds.functionName, err))
+	}
+
+	zerolog.Ctx(ctx).Info().Str("certificateArn", asyncEvent.State.CertificateArn).Int("invocations", asyncEvent.State.Invocations).
+		Msg("invoked function to continue validation")
+
+	return nil
+}
+
+// respond sends the outcome of an asynchronous request to cloudformation
+func (ds *Dispatcher) respond(ctx context.Context, event cfn.Event, started time.Time, physicalResourceID string, err error) error {
+	ds.recordRequest(event, started, err)
+
+	r := cfn.NewResponse(&event)
+
+	r.PhysicalResourceID = physicalResourceID
+	if r.PhysicalResourceID == "" {
+		r.PhysicalResourceID = lambdacontext.LogStreamName
+	}
+
+	r.Status = cfn.StatusSuccess
+
+	if err != nil {
+		r.Status = cfn.StatusFailed
+		r.Reason = err.Error()
+		zerolog.Ctx(ctx).Error().Err(err).Str("certificateArn", physicalResourceID).Msg("sending status failed")
+	}
+
+	return ds.sendResponse(r)
+}
+
+func sendResponse(r *cfn.Response) error {
+	return r.Send()
+}
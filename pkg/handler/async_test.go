@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wolfeidau/serverless-acm-approver/mocks"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+type fakeInvoker struct {
+	inputs []*lambda.InvokeInput
+}
+
+func (fi *fakeInvoker) InvokeWithContext(ctx aws.Context, input *lambda.InvokeInput, opts ...request.Option) (*lambda.InvokeOutput, error) {
+	fi.inputs = append(fi.inputs, input)
+	return &lambda.InvokeOutput{}, nil
+}
+
+type fakeResponder struct {
+	responses []*cfn.Response
+}
+
+func (fr *fakeResponder) send(r *cfn.Response) error {
+	fr.responses = append(fr.responses, r)
+	return nil
+}
+
+var asyncEvent = cfn.Event{
+	RequestID:          "abc123",
+	PhysicalResourceID: "cde456",
+	RequestType:        cfn.RequestCreate,
+	ResourceProperties: map[string]interface{}{
+		"DomainName":              "t.1.co",
+		"ServiceToken":            "arn",
+		"SubjectAlternativeNames": []string{""},
+		"Async":                   "true",
+	},
+}
+
+func TestHandle_AsyncStart(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(nil)
+	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}, gomock.Any()).Return("ghi789", nil)
+	cert.EXPECT().Publish(gomock.Any(), "ghi789", gomock.Any()).Return(nil)
+
+	invoker, responder := &fakeInvoker{}, &fakeResponder{}
+
+	dispatcher := &Dispatcher{certApprover: cert, invoker: invoker, functionName: "approver", sendResponse: responder.send}
+
+	payload, err := json.Marshal(asyncEvent)
+	assert.NoError(err)
+
+	err = dispatcher.Handle(context.TODO(), payload)
+	assert.NoError(err)
+	assert.Empty(responder.responses)
+	assert.Len(invoker.inputs, 1)
+	assert.Equal("approver", aws.StringValue(invoker.inputs[0].FunctionName))
+	assert.Equal(lambda.InvocationTypeEvent, aws.StringValue(invoker.inputs[0].InvocationType))
+
+	next := new(AsyncEvent)
+	assert.NoError(json.Unmarshal(invoker.inputs[0].Payload, next))
+	assert.Equal("ghi789", next.State.CertificateArn)
+	assert.Equal(1, next.State.Invocations)
+	assert.Equal("abc123", next.Event.RequestID)
+}
+
+func TestHandle_AsyncContinue(t *testing.T) {
+	tests := []struct {
+		name        string
+		startedAt   time.Time
+		waitErr     error
+		invocations int
+		status      cfn.StatusType
+	}{
+		{name: "issued certificate should respond with success", startedAt: time.Now(), status: cfn.StatusSuccess},
+		{name: "pending certificate should invoke again", startedAt: time.Now(), waitErr: &approver.TimeoutError{Phase: "waiting"}, invocations: 1},
+		{name: "certificate pending after max attempts should invoke again", startedAt: time.Now(), waitErr: fmt.Errorf("ghi789: %w", approver.ErrValidationPending), invocations: 1},
+		{name: "failed certificate should respond with failure", startedAt: time.Now(), waitErr: &approver.ValidationError{CertificateArn: "ghi789", Status: "FAILED", FailureReason: "CAA_ERROR"}, status: cfn.StatusFailed},
+		{name: "pending certificate past timeout should respond with failure", startedAt: time.Now().Add(-2 * time.Hour), waitErr: &approver.TimeoutError{Phase: "waiting"}, status: cfn.StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cert := mocks.NewMockCertificate(ctrl)
+
+			cert.EXPECT().WaitForValidation(gomock.Any(), "ghi789").Return(tt.waitErr)
+
+			invoker, responder := &fakeInvoker{}, &fakeResponder{}
+
+			dispatcher := &Dispatcher{certApprover: cert, invoker: invoker, functionName: "approver", sendResponse: responder.send}
+
+			payload, err := json.Marshal(&AsyncEvent{
+				Event: asyncEvent,
+				State: &AsyncState{CertificateArn: "ghi789", StartedAt: tt.startedAt, Invocations: 1},
+			})
+			assert.NoError(err)
+
+			err = dispatcher.Handle(context.TODO(), payload)
+			assert.NoError(err)
+			assert.Len(invoker.inputs, tt.invocations)
+
+			if tt.status == "" {
+				assert.Empty(responder.responses)
+				return
+			}
+
+			assert.Len(responder.responses, 1)
+			assert.Equal(tt.status, responder.responses[0].Status)
+			assert.Equal("ghi789", responder.responses[0].PhysicalResourceID)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+// describeError prefixes the error with what went wrong based on its class, this makes it clear in
+// the cloudformation failure reason what to do about it
+func describeError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, approver.ErrThrottled):
+		return fmt.Errorf("AWS throttled requests from the approver, retry the stack operation later: %w", err)
+	case errors.Is(err, approver.ErrInvalidInput):
+		return fmt.Errorf("invalid certificate request: %w", err)
+	case errors.Is(err, approver.ErrValidationFailed):
+		return fmt.Errorf("ACM failed to validate the certificate: %w", err)
+	case errors.Is(err, approver.ErrInUse):
+		return fmt.Errorf("certificate can't be deleted while it is in use, remove it from these resources or set InUsePolicy to Retain: %w", err)
+	}
+
+	var timeoutErr *approver.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	if errors.Is(err, approver.ErrTimeout) {
+		return fmt.Errorf("gave up waiting, increase MaxAttempts or enable Async for certificates which take longer to validate: %w", err)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("request did not complete before the lambda timeout: %w", err)
+	}
+
+	return err
+}
+
+// isRetryable checks if asynchronous validation should continue after the error, which is the case
+// when the approver ran out of time or was throttled before the certificate was issued or failed
+func isRetryable(err error) bool {
+	return errors.Is(err, approver.ErrTimeout) || errors.Is(err, approver.ErrThrottled)
+}
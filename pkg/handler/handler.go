@@ -2,31 +2,67 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/mitchellh/mapstructure"
-	"github.com/rs/zerolog/log"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/api/key"
 
 	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/tracing"
 )
 
 const (
 	maxDomainNameLength = 64
+
+	// time reserved at the end of the lambda execution to send the response to cloudformation
+	responseReserve = 15 * time.Second
 )
 
 // Dispatcher dispatches handler requests and holds approver helper
 type Dispatcher struct {
 	certApprover approver.Certificate
+	newApprover  func(opts ...approver.Option) approver.Certificate
+	options      []approver.Option
+	invoker      Invoker
+	functionName string
+	sendResponse func(r *cfn.Response) error
+	logger       *zerolog.Logger
+	metrics      *metrics.Metrics
 }
 
 // New create a new dispatcher of handlers
 func New(config ...*aws.Config) *Dispatcher {
+	opts := []approver.Option{}
+
+	for _, c := range config {
+		opts = append(opts, approver.WithConfig(c))
+	}
+
+	// metrics are written to stdout using the embedded metric format which cloudwatch extracts from the logs
+	m := metrics.New(os.Stdout)
+
+	opts = append(opts, approver.WithMetrics(m))
+
+	sess := session.Must(session.NewSession(config...))
+
 	return &Dispatcher{
-		certApprover: approver.New(config...),
+		certApprover: approver.New(opts...),
+		newApprover:  approver.New,
+		options:      opts,
+		invoker:      lambda.New(sess),
+		functionName: lambdacontext.FunctionName,
+		sendResponse: sendResponse,
+		metrics:      m,
 	}
 }
 
@@ -34,9 +70,19 @@ func New(config ...*aws.Config) *Dispatcher {
 type Params struct {
 	DomainName              string
 	HostedZoneId            string
+	HostedZones             map[string]string
 	ServiceToken            string
 	SubjectAlternativeNames []string
 	Region                  string
+	Route53RoleArn          string
+	Route53ExternalId       string
+	InUsePolicy             string
+	Async                   bool
+	AsyncTimeout            time.Duration
+	ReuseExisting           bool
+	DryRun                  bool
+	Tags                    []Tag
+	Tuning                  `mapstructure:",squash"`
 }
 
 // Validate checks the params are valid
@@ -53,8 +99,36 @@ func (p *Params) Validate() error {
 		return errors.New("missing required ServiceToken")
 	}
 
-	if p.HostedZoneId == "" {
-		return errors.New("missing required HostedZoneId")
+	if p.Route53RoleArn != "" && !strings.HasPrefix(p.Route53RoleArn, "arn:") {
+		return errors.New("Route53RoleArn must be a valid role ARN")
+	}
+
+	if p.Route53ExternalId != "" && p.Route53RoleArn == "" {
+		return errors.New("Route53ExternalId requires Route53RoleArn")
+	}
+
+	switch approver.InUsePolicy(p.InUsePolicy) {
+	case "", approver.InUseFail, approver.InUseRetain, approver.InUseWait:
+	default:
+		return errors.New("InUsePolicy must be one of Fail, Retain or Wait")
+	}
+
+	for domain, hostedZoneID := range p.HostedZones {
+		if domain == "" || hostedZoneID == "" {
+			return errors.New("HostedZones entries require both a domain and a hosted zone id")
+		}
+	}
+
+	if p.AsyncTimeout < 0 || p.AsyncTimeout > maxAsyncTimeout {
+		return fmt.Errorf("AsyncTimeout must be between 0 and %s as cloudformation stops waiting for a response after an hour", maxAsyncTimeout)
+	}
+
+	if err := validateTags(p.Tags); err != nil {
+		return err
+	}
+
+	if err := p.Tuning.Validate(); err != nil {
+		return err
 	}
 
 	if p.SubjectAlternativeNames == nil {
@@ -74,57 +148,195 @@ func (p *Params) Validate() error {
 	return nil
 }
 
+// ApproverOptions returns the options which override the default approver for this request
+func (p *Params) ApproverOptions() []approver.Option {
+	opts := []approver.Option{}
+
+	// if a region is passed in then override the client to use it, this is primarily to support
+	// targeting us-east-1 for ACM certificates used by cloudfront
+	if p.Region != "" {
+		opts = append(opts, approver.WithConfig(aws.NewConfig().WithRegion(p.Region)))
+	}
+
+	// DNS may live in another account so route53 calls use credentials from the assumed role
+	if p.Route53RoleArn != "" {
+		opts = append(opts, approver.WithRoute53Role(p.Route53RoleArn, p.Route53ExternalId))
+	}
+
+	return append(opts, p.Tuning.Options()...)
+}
+
+// DeleteInUsePolicy returns the policy used when deleting a certificate which is still in use
+func (p *Params) DeleteInUsePolicy() approver.InUsePolicy {
+	if p.InUsePolicy == "" {
+		return approver.InUseFail
+	}
+
+	return approver.InUsePolicy(p.InUsePolicy)
+}
+
+func (p *Params) asyncTimeout() time.Duration {
+	if p.AsyncTimeout == 0 {
+		return defaultAsyncTimeout
+	}
+
+	return p.AsyncTimeout
+}
+
+// Zones returns the hosted zones used to publish validation records
+func (p *Params) Zones() approver.Zones {
+	return approver.Zones{
+		HostedZoneID: p.HostedZoneId,
+		Domains:      p.HostedZones,
+	}
+}
+
 // CreateAndApproveACMCertificate custom cfn certificate creation function
 func (ds *Dispatcher) CreateAndApproveACMCertificate(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
-	jsonData, _ := json.Marshal(&event.ResourceProperties)
+	ctx = ds.eventContext(ctx, event)
 
-	fmt.Println(string(jsonData))
+	zerolog.Ctx(ctx).Info().Str("RequestType", string(event.RequestType)).
+		Interface("ResourceProperties", redactProperties(event.ResourceProperties)).Msg("received event")
 
-	data := map[string]interface{}{}
+	ctx, span := tracing.Start(ctx, "CreateAndApproveACMCertificate",
+		key.String("cfn.request_type", string(event.RequestType)),
+		key.String("cfn.request_id", event.RequestID),
+		key.String("cfn.stack_id", event.StackID),
+		key.String("cfn.logical_resource_id", event.LogicalResourceID),
+	)
 
-	params := new(Params)
+	start := time.Now()
+
+	physicalResourceID, data, err := ds.dispatch(ctx, event)
+
+	ds.recordRequest(event, start, err)
+
+	span.SetAttributes(key.String("cfn.physical_resource_id", physicalResourceID))
+	tracing.End(ctx, span, err)
+
+	return physicalResourceID, data, err
+}
+
+// dispatch processes the cloudformation event returning the physical resource id of the certificate
+func (ds *Dispatcher) dispatch(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
+	data := map[string]interface{}{}
 
-	err := mapstructure.Decode(event.ResourceProperties, params)
+	params, certApprover, err := ds.prepare(event)
 	if err != nil {
 		return event.PhysicalResourceID, data, err
 	}
 
-	err = params.Validate()
-	if err != nil {
-		return event.PhysicalResourceID, data, err
+	// the approver stops before the lambda is killed leaving time to send cloudformation a response
+	ctx, cancel := withResponseReserve(ctx)
+	defer cancel()
+
+	// once dry run is turned off creates and updates request a certificate, cloudformation then deletes
+	// the dry run resource which has nothing to clean up
+	if params.DryRun || (event.RequestType == cfn.RequestDelete && isPlanned(event)) {
+		return ds.plan(ctx, certApprover, event, params)
 	}
 
-	// using the default cert approver to ensure we can test this method
-	certApprover := ds.certApprover
+	if isInPlaceUpdate(event) {
+		err = ds.updateTags(ctx, certApprover, event, params)
+		if err != nil {
+			return event.PhysicalResourceID, data, describeError(ctx, err)
+		}
 
-	// if a region is passed in then override the client to use it, this is primarily to support
-	// targeting us-east-1 for ACM certificates used by cloudfront
-	if params.Region != "" {
-		certApprover = approver.New(aws.NewConfig().WithRegion(params.Region))
+		return event.PhysicalResourceID, data, nil
 	}
 
 	switch event.RequestType {
 	case cfn.RequestDelete:
-		err := ds.certApprover.Delete(ctx, event.PhysicalResourceID)
+		owned, err := isOwned(ctx, certApprover, event, params)
 		if err != nil {
-			return event.PhysicalResourceID, data, err
+			return event.PhysicalResourceID, data, describeError(ctx, err)
+		}
+
+		if !owned {
+			return event.PhysicalResourceID, data, nil
+		}
+
+		err = certApprover.Delete(ctx, event.PhysicalResourceID, params.Zones(), params.DeleteInUsePolicy())
+		if err != nil {
+			return event.PhysicalResourceID, data, describeError(ctx, err)
 		}
 
 		return event.PhysicalResourceID, data, nil
 	case cfn.RequestCreate, cfn.RequestUpdate:
-		certificateARN, err := certApprover.Request(ctx, event.RequestID, params.DomainName, params.SubjectAlternativeNames)
+		existingARN, err := findExisting(ctx, certApprover, params)
+		if err != nil {
+			return "", data, describeError(ctx, err)
+		}
+
+		if existingARN != "" {
+			return existingARN, data, nil
+		}
+
+		err = certApprover.Preflight(ctx, params.DomainName, params.SubjectAlternativeNames, params.Zones())
+		if err != nil {
+			return "", data, describeError(ctx, err)
+		}
+
+		certificateARN, err := certApprover.Request(ctx, event.RequestID, params.DomainName, params.SubjectAlternativeNames, certificateTags(event, params))
 		if err != nil {
-			return "", data, err
+			return "", data, describeError(ctx, err)
 		}
 
-		err = certApprover.Approve(ctx, certificateARN, params.HostedZoneId)
+		err = certApprover.Approve(ctx, certificateARN, params.Zones())
 		if err != nil {
-			return certificateARN, data, err
+			return certificateARN, data, describeError(ctx, err)
 		}
 
 		return certificateARN, data, nil
 	default:
-		log.Warn().Str("RequestType", string(event.RequestType)).Str("RequestID", event.RequestID).Msg("no handler for event")
+		zerolog.Ctx(ctx).Warn().Str("RequestType", string(event.RequestType)).Msg("no handler for event")
 		return event.PhysicalResourceID, data, nil
 	}
 }
+
+// updateTags applies the tags to the certificate in place, certificates which were reused are left unchanged
+func (ds *Dispatcher) updateTags(ctx context.Context, certApprover approver.Certificate, event cfn.Event, params *Params) error {
+	owned, err := isOwned(ctx, certApprover, event, params)
+	if err != nil || !owned {
+		return err
+	}
+
+	return certApprover.Tag(ctx, event.PhysicalResourceID, certificateTags(event, params), removedTagKeys(event, params))
+}
+
+// prepare decodes and validates the resource properties then selects the approver used for the event
+func (ds *Dispatcher) prepare(event cfn.Event) (*Params, approver.Certificate, error) {
+	params := new(Params)
+
+	err := decodeProperties(withTuningEnv(event.ResourceProperties), params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = params.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// using the default cert approver to ensure we can test this method
+	certApprover := ds.certApprover
+
+	if opts := params.ApproverOptions(); len(opts) > 0 {
+		opts = append(append([]approver.Option{}, ds.options...), opts...)
+		certApprover = ds.newApprover(opts...)
+	}
+
+	return params, certApprover, nil
+}
+
+// withResponseReserve shortens the deadline of the lambda context to leave time to respond
+func withResponseReserve(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	zerolog.Ctx(ctx).Info().Dur("remaining", time.Until(deadline)).Msg("lambda deadline")
+
+	return context.WithDeadline(ctx, deadline.Add(-responseReserve))
+}
@@ -1,19 +1,24 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/cfn"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/golang/mock/gomock"
 	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
 	"github.com/wolfeidau/serverless-acm-approver/mocks"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
 )
 
 var paramsJSON = `
@@ -41,6 +46,33 @@ func TestDecodeJSON(t *testing.T) {
 	assert.Equal(&Params{DomainName: "t.1.co", HostedZoneId: "QA8Q", ServiceToken: "arn", SubjectAlternativeNames: []string{""}}, params)
 }
 
+func TestDecodeProperties_Tuning(t *testing.T) {
+	assert := require.New(t)
+
+	for k, v := range map[string]string{"APPROVER_RECORD_TTL": "300", "APPROVER_MAX_ATTEMPTS": "10"} {
+		assert.NoError(os.Setenv(k, v))
+		defer func(k string) { assert.NoError(os.Unsetenv(k)) }(k)
+	}
+
+	params := new(Params)
+
+	err := decodeProperties(withTuningEnv(map[string]interface{}{
+		"DomainName":         "t.1.co",
+		"MaxAttempts":        "5",
+		"ValidationPollTime": "1m",
+		"BackoffMaxDelay":    "2m30s",
+	}), params)
+	assert.NoError(err)
+
+	assert.Equal(Tuning{
+		MaxAttempts:        5,
+		RecordTTL:          300,
+		ValidationPollTime: time.Minute,
+		BackoffMaxDelay:    150 * time.Second,
+	}, params.Tuning)
+	assert.Len(params.ApproverOptions(), 4)
+}
+
 func TestValidate(t *testing.T) {
 	assert := require.New(t)
 
@@ -53,8 +85,14 @@ func TestParams_Validate(t *testing.T) {
 	type fields struct {
 		DomainName              string
 		HostedZoneId            string
+		HostedZones             map[string]string
 		ServiceToken            string
 		SubjectAlternativeNames []string
+		Route53RoleArn          string
+		Route53ExternalId       string
+		InUsePolicy             string
+		AsyncTimeout            time.Duration
+		Tags                    []Tag
 	}
 	tests := []struct {
 		name    string
@@ -89,12 +127,71 @@ func TestParams_Validate(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "validate with missing HostedZoneId should return error",
+			name: "validate with missing HostedZoneId should return no error",
 			fields: fields{
 				DomainName:              "t.1.co",
 				ServiceToken:            "arn",
 				SubjectAlternativeNames: []string{"", "b.l.co"},
 			},
+		},
+		{
+			name: "validate with empty HostedZones entry should return error",
+			fields: fields{
+				DomainName:              "t.1.co",
+				ServiceToken:            "arn",
+				HostedZones:             map[string]string{"t.1.co": ""},
+				SubjectAlternativeNames: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "validate with Route53ExternalId and no Route53RoleArn should return error",
+			fields: fields{
+				DomainName:              "t.1.co",
+				ServiceToken:            "arn",
+				Route53ExternalId:       "abc",
+				SubjectAlternativeNames: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "validate with invalid Route53RoleArn should return error",
+			fields: fields{
+				DomainName:              "t.1.co",
+				ServiceToken:            "arn",
+				Route53RoleArn:          "dns-role",
+				SubjectAlternativeNames: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "validate with unknown InUsePolicy should return error",
+			fields: fields{
+				DomainName:              "t.1.co",
+				ServiceToken:            "arn",
+				InUsePolicy:             "Delete",
+				SubjectAlternativeNames: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "validate with AsyncTimeout beyond the cloudformation timeout should return error",
+			fields: fields{
+				DomainName:              "t.1.co",
+				ServiceToken:            "arn",
+				AsyncTimeout:            time.Hour,
+				SubjectAlternativeNames: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "validate with reserved tag key should return error",
+			fields: fields{
+				DomainName:              "t.1.co",
+				ServiceToken:            "arn",
+				Tags:                    []Tag{{Key: "serverless-acm-approver:version", Value: "1"}},
+				SubjectAlternativeNames: []string{""},
+			},
 			wantErr: true,
 		},
 		{
@@ -112,8 +209,14 @@ func TestParams_Validate(t *testing.T) {
 			p := &Params{
 				DomainName:              tt.fields.DomainName,
 				HostedZoneId:            tt.fields.HostedZoneId,
+				HostedZones:             tt.fields.HostedZones,
 				ServiceToken:            tt.fields.ServiceToken,
 				SubjectAlternativeNames: tt.fields.SubjectAlternativeNames,
+				Route53RoleArn:          tt.fields.Route53RoleArn,
+				Route53ExternalId:       tt.fields.Route53ExternalId,
+				InUsePolicy:             tt.fields.InUsePolicy,
+				AsyncTimeout:            tt.fields.AsyncTimeout,
+				Tags:                    tt.fields.Tags,
 			}
 			if err := p.Validate(); (err != nil) != tt.wantErr {
 				t.Errorf("Params.Validate() error = %v, wantErr %v", err, tt.wantErr)
@@ -130,7 +233,17 @@ func TestCertRequestCreate(t *testing.T) {
 
 	cert := mocks.NewMockCertificate(ctrl)
 
-	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}).Return("ghi789", nil)
+	tags := map[string]string{
+		"Team":                        "platform",
+		approver.TagStackID:           "arn:aws:cloudformation:us-east-1:123456789012:stack/test/1",
+		approver.TagLogicalResourceID: "Certificate",
+		approver.TagVersion:           approver.Version,
+		approver.TagZones:             "QA8Q",
+		approver.TagRoute53RoleArn:    "",
+	}
+
+	cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(nil)
+	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}, tags).Return("ghi789", nil)
 	cert.EXPECT().Approve(gomock.Any(), "ghi789", gomock.Any()).Return(nil)
 
 	dispatcher := &Dispatcher{certApprover: cert}
@@ -139,11 +252,16 @@ func TestCertRequestCreate(t *testing.T) {
 		RequestID:          "abc123",
 		PhysicalResourceID: "cde456",
 		RequestType:        cfn.RequestCreate,
+		StackID:            "arn:aws:cloudformation:us-east-1:123456789012:stack/test/1",
+		LogicalResourceID:  "Certificate",
 		ResourceProperties: map[string]interface{}{
 			"DomainName":              "t.1.co",
 			"HostedZoneId":            "QA8Q",
 			"ServiceToken":            "arn",
 			"SubjectAlternativeNames": []string{""},
+			"Tags": []interface{}{
+				map[string]interface{}{"Key": "Team", "Value": "platform"},
+			},
 		},
 	}
 
@@ -161,8 +279,14 @@ func TestCertRequestCreate_ApproveError(t *testing.T) {
 
 	cert := mocks.NewMockCertificate(ctrl)
 
-	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}).Return("ghi789", nil)
-	cert.EXPECT().Approve(gomock.Any(), "ghi789", gomock.Any()).Return(awserr.New(request.WaiterResourceNotReadyErrorCode, "failed", errors.New("something broke")))
+	cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(nil)
+	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}, gomock.Any()).Return("ghi789", nil)
+	cert.EXPECT().Approve(gomock.Any(), "ghi789", gomock.Any()).Return(&approver.ValidationError{
+		CertificateArn: "ghi789",
+		Status:         "FAILED",
+		FailureReason:  "CAA_ERROR",
+		Domains:        []approver.DomainStatus{{DomainName: "t.1.co", ValidationStatus: "FAILED"}},
+	})
 
 	dispatcher := &Dispatcher{certApprover: cert}
 
@@ -178,8 +302,405 @@ func TestCertRequestCreate_ApproveError(t *testing.T) {
 		},
 	}
 
-	_, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.EqualError(err, "ACM failed to validate the certificate: certificate ghi789 is FAILED with reason CAA_ERROR (t.1.co FAILED)")
+	assert.Equal("ghi789", physicalID)
+}
+
+func TestCertRequestUpdate_TagsOnly(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Tag(gomock.Any(), "cde456", gomock.Any(), []string{"Owner"}).DoAndReturn(
+		func(ctx context.Context, arn string, tags map[string]string, removeKeys []string) error {
+			assert.Equal("platform", tags["Team"])
+			assert.Equal("Certificate", tags[approver.TagLogicalResourceID])
+			return nil
+		})
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		PhysicalResourceID: "cde456",
+		RequestType:        cfn.RequestUpdate,
+		LogicalResourceID:  "Certificate",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"Tags": []interface{}{
+				map[string]interface{}{"Key": "Team", "Value": "platform"},
+			},
+		},
+		OldResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"Tags": []interface{}{
+				map[string]interface{}{"Key": "Owner", "Value": "someone"},
+			},
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("cde456", physicalID)
+}
+
+func TestCertRequestUpdate_BehaviourOnly(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	// the existing certificate is kept so it isn't deleted while in use
+	cert.EXPECT().Tag(gomock.Any(), "cde456", gomock.Any(), []string{}).Return(nil)
+
+	dispatcher := &Dispatcher{certApprover: cert, newApprover: func(opts ...approver.Option) approver.Certificate {
+		return cert
+	}}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		PhysicalResourceID: "cde456",
+		RequestType:        cfn.RequestUpdate,
+		StackID:            "this-stack",
+		LogicalResourceID:  "Certificate",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"InUsePolicy":             "Retain",
+			"Async":                   "true",
+			"MaxAttempts":             "40",
+			"BackoffMaxDelay":         "1m",
+		},
+		OldResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+		},
+	}
+
+	assert.False(isAsync(event))
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("cde456", physicalID)
+}
+
+func TestCertRequestUpdate_ReuseExistingOff(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	// turning off ReuseExisting requests a certificate for the resource rather than tagging the reused
+	// one as belonging to this stack, cloudformation then leaves the reused certificate in place
+	gomock.InOrder(
+		cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{"*.t.1.co"}, gomock.Any()).Return(nil),
+		cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{"*.t.1.co"}, gomock.Any()).Return("ghi789", nil),
+		cert.EXPECT().Approve(gomock.Any(), "ghi789", gomock.Any()).Return(nil),
+	)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		PhysicalResourceID: "foreign-cert",
+		RequestType:        cfn.RequestUpdate,
+		StackID:            "this-stack",
+		LogicalResourceID:  "Certificate",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"ReuseExisting":           "false",
+		},
+		OldResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"ReuseExisting":           "true",
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("ghi789", physicalID)
+
+	// the delete which follows checks the tags so the reused certificate isn't removed
+	cert.EXPECT().Tags(gomock.Any(), "foreign-cert").Return(map[string]string{
+		approver.TagStackID: "other-stack", approver.TagLogicalResourceID: "Certificate",
+	}, nil)
+
+	deleteEvent := cfn.Event{
+		RequestID:          "def456",
+		PhysicalResourceID: "foreign-cert",
+		RequestType:        cfn.RequestDelete,
+		StackID:            "this-stack",
+		LogicalResourceID:  "Certificate",
+		ResourceProperties: event.OldResourceProperties,
+	}
+
+	physicalID, _, err = dispatcher.CreateAndApproveACMCertificate(context.TODO(), deleteEvent)
+	assert.NoError(err)
+	assert.Equal("foreign-cert", physicalID)
+}
+
+func TestCertRequestCreate_PreflightError(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(errors.New("CAA records on t.1.co. don't allow ACM"))
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:   "abc123",
+		RequestType: cfn.RequestCreate,
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
 	assert.Error(err)
+	assert.Empty(physicalID)
+}
+
+func TestCertRequestCreate_DryRun(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().PlanRequest(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(&approver.Plan{
+		Calls: []approver.PlannedCall{{Service: "acm", Operation: "RequestCertificate", Target: "t.1.co"}},
+	}, nil)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:   "abc123",
+		RequestType: cfn.RequestCreate,
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"DryRun":                  "true",
+		},
+	}
+
+	physicalID, data, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("dry-run:abc123", physicalID)
+	assert.Equal("acm RequestCertificate t.1.co", data["Plan"])
+	assert.False(isAsync(cfn.Event{RequestType: cfn.RequestCreate, ResourceProperties: map[string]interface{}{"Async": "true", "DryRun": "true"}}))
+}
+
+func TestCertRequestDelete_DryRun(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().PlanDelete(gomock.Any(), "cde456", gomock.Any(), approver.InUseFail).Return(&approver.Plan{}, nil)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		RequestType:        cfn.RequestDelete,
+		PhysicalResourceID: "cde456",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"DryRun":                  "true",
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("cde456", physicalID)
+
+	// resources created by a dry run are removed without any calls
+	event.PhysicalResourceID = "dry-run:abc123"
+	delete(event.ResourceProperties, "DryRun")
+
+	physicalID, _, err = dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("dry-run:abc123", physicalID)
+}
+
+func TestCertRequestUpdate_DryRunOff(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	// turning off dry run requests the certificate, cloudformation then deletes the dry run resource
+	gomock.InOrder(
+		cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(nil),
+		cert.EXPECT().Request(gomock.Any(), "def456", "t.1.co", []string{}, gomock.Any()).Return("ghi789", nil),
+		cert.EXPECT().Approve(gomock.Any(), "ghi789", gomock.Any()).Return(nil),
+	)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "def456",
+		RequestType:        cfn.RequestUpdate,
+		PhysicalResourceID: "dry-run:abc123",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"DryRun":                  "false",
+		},
+		OldResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"DryRun":                  "true",
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("ghi789", physicalID)
+
+	// only the tags changed since the dry run, but there is still no certificate to update in place
+	event.ResourceProperties["Tags"] = []interface{}{map[string]interface{}{"Key": "Team", "Value": "platform"}}
+	event.ResourceProperties["Async"] = "true"
+	event.ResourceProperties["DryRun"] = "false"
+	event.OldResourceProperties["DryRun"] = "false"
+
+	assert.True(isAsync(event))
+}
+
+func TestCertRequestUpdate_DryRunOffCertificate(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	// the certificate is kept rather than replaced when a dry run of an update is turned off
+	cert.EXPECT().Tag(gomock.Any(), "cde456", gomock.Any(), []string{}).Return(nil)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "def456",
+		RequestType:        cfn.RequestUpdate,
+		PhysicalResourceID: "cde456",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"DryRun":                  "false",
+			"Async":                   "true",
+		},
+		OldResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"DryRun":                  "true",
+			"Async":                   "true",
+		},
+	}
+
+	assert.False(isAsync(event))
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("cde456", physicalID)
+}
+
+func TestCertRequestCreate_ReuseExisting(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().FindIssued(gomock.Any(), "t.1.co", []string{"*.t.1.co"}).Return("existing", nil)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:   "abc123",
+		RequestType: cfn.RequestCreate,
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"ReuseExisting":           "true",
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("existing", physicalID)
+}
+
+func TestCertRequestDelete_Reused(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Tags(gomock.Any(), "existing").Return(map[string]string{
+		approver.TagStackID:           "another-stack",
+		approver.TagLogicalResourceID: "Certificate",
+	}, nil)
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		RequestType:        cfn.RequestDelete,
+		PhysicalResourceID: "existing",
+		StackID:            "this-stack",
+		LogicalResourceID:  "Certificate",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []interface{}{"*.t.1.co"},
+			"ReuseExisting":           "true",
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("existing", physicalID)
 }
 
 func TestCertRequestDelete(t *testing.T) {
@@ -190,7 +711,7 @@ func TestCertRequestDelete(t *testing.T) {
 
 	cert := mocks.NewMockCertificate(ctrl)
 
-	cert.EXPECT().Delete(gomock.Any(), "ghi789").Return(nil)
+	cert.EXPECT().Delete(gomock.Any(), "ghi789", gomock.Any(), approver.InUseFail).Return(nil)
 
 	dispatcher := &Dispatcher{certApprover: cert}
 
@@ -211,3 +732,217 @@ func TestCertRequestDelete(t *testing.T) {
 	assert.Equal("ghi789", physicalID)
 	assert.NotNil(data)
 }
+
+func TestCertRequestDelete_Route53Role(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Delete(gomock.Any(), "ghi789", gomock.Any(), approver.InUseFail).Return(nil)
+
+	var optionCount int
+
+	dispatcher := &Dispatcher{
+		certApprover: mocks.NewMockCertificate(ctrl),
+		newApprover: func(opts ...approver.Option) approver.Certificate {
+			optionCount = len(opts)
+			return cert
+		},
+	}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		PhysicalResourceID: "ghi789",
+		RequestType:        cfn.RequestDelete,
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+			"Region":                  "us-east-1",
+			"Route53RoleArn":          "arn:aws:iam::123456789012:role/dns",
+			"Route53ExternalId":       "abc",
+		},
+	}
+
+	physicalID, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+	assert.Equal("ghi789", physicalID)
+	assert.Equal(2, optionCount)
+}
+
+func TestCertRequestCreate_Timeout(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(nil)
+	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _, _ string, _ []string, _ map[string]string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+
+	dispatcher := &Dispatcher{certApprover: cert}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		PhysicalResourceID: "cde456",
+		RequestType:        cfn.RequestCreate,
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+		},
+	}
+
+	// leaves 100ms after reserving time for the response
+	ctx, cancel := context.WithTimeout(context.TODO(), responseReserve+100*time.Millisecond)
+	defer cancel()
+
+	_, _, err := dispatcher.CreateAndApproveACMCertificate(ctx, event)
+	assert.EqualError(err, "request did not complete before the lambda timeout: context deadline exceeded")
+	assert.NoError(ctx.Err())
+}
+
+func TestDescribeError(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.TODO(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		err       error
+		want      string
+		retryable bool
+	}{
+		{
+			name:      "throttled error should ask for a retry",
+			ctx:       context.TODO(),
+			err:       &approver.APIError{Class: approver.ErrThrottled, Err: errors.New("Rate exceeded")},
+			want:      "AWS throttled requests from the approver, retry the stack operation later: Rate exceeded",
+			retryable: true,
+		},
+		{
+			name: "invalid input error should be described",
+			ctx:  context.TODO(),
+			err:  &approver.APIError{Class: approver.ErrInvalidInput, Err: errors.New("bad domain")},
+			want: "invalid certificate request: bad domain",
+		},
+		{
+			name: "in use error should suggest InUsePolicy",
+			ctx:  context.TODO(),
+			err:  &approver.InUseError{CertificateArn: "ghi789", InUseBy: []string{"lb"}},
+			want: "certificate can't be deleted while it is in use, remove it from these resources or set InUsePolicy to Retain: certificate ghi789 is still in use by 1 resources: lb",
+		},
+		{
+			name:      "pending validation should suggest async",
+			ctx:       context.TODO(),
+			err:       fmt.Errorf("certificate ghi789: %w", approver.ErrValidationPending),
+			want:      "gave up waiting, increase MaxAttempts or enable Async for certificates which take longer to validate: certificate ghi789: certificate is still pending validation",
+			retryable: true,
+		},
+		{
+			name: "error after the deadline should mention the lambda timeout",
+			ctx:  expired,
+			err:  errors.New("RequestCanceled"),
+			want: "request did not complete before the lambda timeout: RequestCanceled",
+		},
+		{
+			name: "other errors should be unchanged",
+			ctx:  context.TODO(),
+			err:  errors.New("AccessDenied"),
+			want: "AccessDenied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			assert.EqualError(describeError(tt.ctx, tt.err), tt.want)
+			assert.Equal(tt.retryable, isRetryable(tt.err))
+		})
+	}
+}
+
+func TestCertRequestCreate_Logging(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Preflight(gomock.Any(), "t.1.co", []string{}, gomock.Any()).Return(nil)
+	cert.EXPECT().Request(gomock.Any(), "abc123", "t.1.co", []string{}, gomock.Any()).Return("ghi789", nil)
+	cert.EXPECT().Approve(gomock.Any(), "ghi789", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, arn string, zones approver.Zones) error {
+			zerolog.Ctx(ctx).Info().Msg("approving")
+			return nil
+		})
+
+	var output bytes.Buffer
+
+	dispatcher := (&Dispatcher{
+		newApprover: func(opts ...approver.Option) approver.Certificate { return cert },
+	}).WithLogger(zerolog.New(&output))
+
+	event := cfn.Event{
+		RequestID:         "abc123",
+		RequestType:       cfn.RequestCreate,
+		StackID:           "stack-1",
+		LogicalResourceID: "Certificate",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn:aws:lambda:us-east-1:123456789012:function:approver",
+			"Route53RoleArn":          "arn:aws:iam::123456789012:role/dns",
+			"Route53ExternalId":       "shared-secret",
+			"SubjectAlternativeNames": []string{""},
+		},
+	}
+
+	_, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.NoError(err)
+
+	assert.Contains(output.String(), `"RequestID":"abc123","StackId":"stack-1","LogicalResourceId":"Certificate","message":"approving"`)
+	assert.Contains(output.String(), `"ServiceToken":"REDACTED"`)
+	assert.NotContains(output.String(), "function:approver")
+	assert.NotContains(output.String(), "shared-secret")
+}
+
+func TestCertRequestDelete_Metrics(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Delete(gomock.Any(), "cde456", gomock.Any(), approver.InUseFail).Return(&approver.InUseError{CertificateArn: "cde456"})
+
+	var output bytes.Buffer
+
+	dispatcher := &Dispatcher{certApprover: cert, metrics: metrics.New(&output)}
+
+	event := cfn.Event{
+		RequestID:          "abc123",
+		RequestType:        cfn.RequestDelete,
+		PhysicalResourceID: "cde456",
+		ResourceProperties: map[string]interface{}{
+			"DomainName":              "t.1.co",
+			"ServiceToken":            "arn",
+			"SubjectAlternativeNames": []string{""},
+		},
+	}
+
+	_, _, err := dispatcher.CreateAndApproveACMCertificate(context.TODO(), event)
+	assert.Error(err)
+
+	assert.Contains(output.String(), `"Operation":"Delete","Outcome":"InUse","RequestLatency"`)
+	assert.Contains(output.String(), `"Failures":1,"Operation":"Delete","Outcome":"InUse"`)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveSuffixes property names ending with these may contain values which shouldn't be logged
+var sensitiveSuffixes = []string{"token", "secret", "password", "externalid"}
+
+// NewLogger creates a logger configured using the LOG_LEVEL environment variable, which defaults to
+// info, and LOG_FORMAT which is either json, the default, or text
+func NewLogger() zerolog.Logger {
+	var logger zerolog.Logger
+
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "text") {
+		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: true})
+	} else {
+		logger = zerolog.New(os.Stderr)
+	}
+
+	logger = logger.With().Timestamp().Logger()
+
+	level, err := zerolog.ParseLevel(strings.ToLower(os.Getenv("LOG_LEVEL")))
+	if err != nil || level == zerolog.NoLevel {
+		if err != nil {
+			logger.Warn().Err(err).Msg("invalid LOG_LEVEL, using info")
+		}
+
+		level = zerolog.InfoLevel
+	}
+
+	return logger.Level(level)
+}
+
+// WithLogger replaces the logger used by the dispatcher, by default the global zerolog logger is used
+func (ds *Dispatcher) WithLogger(logger zerolog.Logger) *Dispatcher {
+	ds.logger = &logger
+
+	return ds
+}
+
+// eventContext adds a logger with the fields identifying the cloudformation request to the context,
+// the approver picks this up so every line logged for the request carries them
+func (ds *Dispatcher) eventContext(ctx context.Context, event cfn.Event) context.Context {
+	logger := log.Logger
+	if ds.logger != nil {
+		logger = *ds.logger
+	}
+
+	logger = logger.With().
+		Str("RequestID", event.RequestID).
+		Str("StackId", event.StackID).
+		Str("LogicalResourceId", event.LogicalResourceID).
+		Logger()
+
+	return logger.WithContext(ctx)
+}
+
+// redactProperties returns a copy of the properties with values which may be sensitive redacted
+func redactProperties(properties map[string]interface{}) map[string]interface{} {
+	redactedProperties := map[string]interface{}{}
+
+	for k, v := range properties {
+		redactedProperties[k] = v
+
+		for _, suffix := range sensitiveSuffixes {
+			if strings.HasSuffix(strings.ToLower(k), suffix) {
+				redactedProperties[k] = redacted
+			}
+		}
+	}
+
+	return redactedProperties
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/aws/aws-lambda-go/cfn"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+)
+
+// metric names recorded by the handler
+const (
+	metricRequestLatency = "RequestLatency"
+	metricFailures       = "Failures"
+)
+
+// recordRequest records the latency of a cloudformation request, and a failure if it returned an error,
+// with the type of request as the operation and the class of error as the outcome
+func (ds *Dispatcher) recordRequest(event cfn.Event, started time.Time, err error) {
+	dims := metrics.Dimensions{
+		metrics.Operation: string(event.RequestType),
+		metrics.Outcome:   approver.Outcome(err),
+	}
+
+	ds.metrics.Duration(metricRequestLatency, time.Since(started), dims)
+
+	if err != nil {
+		ds.metrics.Count(metricFailures, 1, dims)
+	}
+}
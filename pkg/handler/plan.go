@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/rs/zerolog"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+const (
+	// physical resource ids of dry run resources have this prefix so they are never deleted
+	dryRunPrefix = "dry-run:"
+
+	// cloudformation limits responses to 4096 bytes so the plan returned as an attribute is truncated
+	maxPlanLength = 2048
+)
+
+// isPlanned checks the resource was created by a dry run
+func isPlanned(event cfn.Event) bool {
+	return strings.HasPrefix(event.PhysicalResourceID, dryRunPrefix)
+}
+
+// plan describes the calls the event would make without making them, the plan is logged and returned
+// in the Plan attribute of the resource
+func (ds *Dispatcher) plan(ctx context.Context, certApprover approver.Certificate, event cfn.Event, params *Params) (string, map[string]interface{}, error) {
+	data := map[string]interface{}{}
+
+	var (
+		plan *approver.Plan
+		err  error
+	)
+
+	physicalResourceID := event.PhysicalResourceID
+
+	switch event.RequestType {
+	case cfn.RequestDelete:
+		// nothing was created for a dry run so there is nothing to delete
+		if isPlanned(event) {
+			return physicalResourceID, data, nil
+		}
+
+		plan, err = certApprover.PlanDelete(ctx, event.PhysicalResourceID, params.Zones(), params.DeleteInUsePolicy())
+	case cfn.RequestCreate, cfn.RequestUpdate:
+		if event.RequestType == cfn.RequestCreate {
+			physicalResourceID = dryRunPrefix + event.RequestID
+		}
+
+		plan, err = ds.planRequest(ctx, certApprover, event, params)
+	default:
+		zerolog.Ctx(ctx).Warn().Str("RequestType", string(event.RequestType)).Msg("no handler for event")
+		return physicalResourceID, data, nil
+	}
+
+	if err != nil {
+		return physicalResourceID, data, describeError(ctx, err)
+	}
+
+	zerolog.Ctx(ctx).Info().Str("plan", plan.String()).Msg("dry run, no changes were made")
+
+	data["Plan"] = truncate(plan.String(), maxPlanLength)
+
+	return physicalResourceID, data, nil
+}
+
+// planRequest plans a create or update, which either reuses an existing certificate or requests a new one
+func (ds *Dispatcher) planRequest(ctx context.Context, certApprover approver.Certificate, event cfn.Event, params *Params) (*approver.Plan, error) {
+	existingARN, err := findExisting(ctx, certApprover, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingARN != "" {
+		return &approver.Plan{Notes: []string{fmt.Sprintf("issued certificate %s would be reused", existingARN)}}, nil
+	}
+
+	plan, err := certApprover.PlanRequest(ctx, params.DomainName, params.SubjectAlternativeNames, params.Zones())
+	if err != nil {
+		return nil, err
+	}
+
+	if event.RequestType == cfn.RequestUpdate && !isPlanned(event) {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("cloudformation would then delete certificate %s", event.PhysicalResourceID))
+	}
+
+	return plan, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n-3] + "..."
+}
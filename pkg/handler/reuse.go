@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/rs/zerolog"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+// findExisting returns the arn of an issued certificate matching the params when ReuseExisting is
+// enabled, otherwise an empty string
+func findExisting(ctx context.Context, certApprover approver.Certificate, params *Params) (string, error) {
+	if !params.ReuseExisting {
+		return "", nil
+	}
+
+	return certApprover.FindIssued(ctx, params.DomainName, params.SubjectAlternativeNames)
+}
+
+// isOwned checks the certificate was requested for this resource using the provenance tags, when
+// ReuseExisting is, or was before an update, enabled the certificate may belong to something else and
+// is left alone
+func isOwned(ctx context.Context, certApprover approver.Certificate, event cfn.Event, params *Params) (bool, error) {
+	if !params.ReuseExisting && !wasReuseExisting(event) {
+		return true, nil
+	}
+
+	tags, err := certApprover.Tags(ctx, event.PhysicalResourceID)
+	if err != nil {
+		return false, err
+	}
+
+	owned := tags[approver.TagStackID] == event.StackID && tags[approver.TagLogicalResourceID] == event.LogicalResourceID
+
+	if !owned {
+		zerolog.Ctx(ctx).Info().Str("certificateArn", event.PhysicalResourceID).Msg("certificate was reused rather than requested, leaving it unchanged")
+	}
+
+	return owned, nil
+}
+
+// wasReuseExisting checks if ReuseExisting was enabled in the properties before an update
+func wasReuseExisting(event cfn.Event) bool {
+	if event.OldResourceProperties == nil {
+		return false
+	}
+
+	oldParams := new(Params)
+
+	// old properties were valid when they were applied, so only ReuseExisting is of interest here
+	if err := decodeProperties(event.OldResourceProperties, oldParams); err != nil {
+		return false
+	}
+
+	return oldParams.ReuseExisting
+}
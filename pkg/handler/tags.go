@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/aws/aws-lambda-go/cfn"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+// Tag a key and value applied to the certificate, this matches the format of tags on cloudformation resources
+type Tag struct {
+	Key   string
+	Value string
+}
+
+func validateTags(tags []Tag) error {
+	for _, tag := range tags {
+		if tag.Key == "" {
+			return errors.New("Tags require a Key")
+		}
+
+		if approver.ReservedTag(tag.Key) {
+			return errors.New("Tags must not use keys starting with aws: or serverless-acm-approver:")
+		}
+	}
+
+	return nil
+}
+
+// certificateTags returns the tags from the params along with tags recording the stack and resource
+// which own the certificate and the version of the approver
+func certificateTags(event cfn.Event, params *Params) map[string]string {
+	tags := map[string]string{}
+
+	for _, tag := range params.Tags {
+		tags[tag.Key] = tag.Value
+	}
+
+	tags[approver.TagStackID] = event.StackID
+	tags[approver.TagLogicalResourceID] = event.LogicalResourceID
+	tags[approver.TagVersion] = approver.Version
+
+	// zones which don't fit in a tag are discovered when the validation records are republished
+	if zones, ok := approver.ZonesTag(params.Zones()); ok {
+		tags[approver.TagZones] = zones
+	}
+
+	tags[approver.TagRoute53RoleArn] = params.Route53RoleArn
+
+	return tags
+}
+
+// removedTagKeys returns the keys of tags in the old properties which have been removed
+func removedTagKeys(event cfn.Event, params *Params) []string {
+	oldParams := new(Params)
+
+	// old properties were valid when they were applied, so only the tags are of interest here
+	if err := decodeProperties(event.OldResourceProperties, oldParams); err != nil {
+		return nil
+	}
+
+	current := map[string]bool{}
+
+	for _, tag := range params.Tags {
+		current[tag.Key] = true
+	}
+
+	keys := []string{}
+
+	for _, tag := range oldParams.Tags {
+		if !current[tag.Key] {
+			keys = append(keys, tag.Key)
+		}
+	}
+
+	return keys
+}
+
+// inPlaceProperties only change the tags on the certificate or how the approver behaves, so changing
+// them doesn't require a new certificate. ReuseExisting isn't one of these as it changes which
+// certificate the resource uses. DryRun only plans changes, so turning it off for a certificate keeps
+// the certificate, resources created by a dry run are never updated in place.
+var inPlaceProperties = map[string]bool{
+	"Tags":         true,
+	"InUsePolicy":  true,
+	"Async":        true,
+	"AsyncTimeout": true,
+	"DryRun":       true,
+}
+
+// isInPlaceUpdate checks if an update only changes the tags or behaviour of the approver, these are
+// applied to the existing certificate rather than replacing it, dry runs have no certificate to update
+func isInPlaceUpdate(event cfn.Event) bool {
+	if event.RequestType != cfn.RequestUpdate || event.OldResourceProperties == nil || isPlanned(event) {
+		return false
+	}
+
+	return reflect.DeepEqual(withoutInPlace(event.OldResourceProperties), withoutInPlace(event.ResourceProperties))
+}
+
+func withoutInPlace(properties map[string]interface{}) map[string]interface{} {
+	filtered := map[string]interface{}{}
+
+	for k, v := range properties {
+		if _, tuning := tuningEnv[k]; !inPlaceProperties[k] && !tuning {
+			filtered[k] = v
+		}
+	}
+
+	return filtered
+}
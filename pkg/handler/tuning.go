@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+)
+
+// tuningEnv maps each tuning property to the environment variable which provides its default
+var tuningEnv = map[string]string{
+	"MaxAttempts":        "APPROVER_MAX_ATTEMPTS",
+	"RecordTTL":          "APPROVER_RECORD_TTL",
+	"DescribePollTime":   "APPROVER_DESCRIBE_POLL_TIME",
+	"ChangePollTime":     "APPROVER_CHANGE_POLL_TIME",
+	"ValidationPollTime": "APPROVER_VALIDATION_POLL_TIME",
+	"DeletionPollTime":   "APPROVER_DELETION_POLL_TIME",
+	"BackoffMaxDelay":    "APPROVER_BACKOFF_MAX_DELAY",
+}
+
+// Tuning overrides the approver timing and retry behaviour, durations use the go duration
+// format, for example 30s or 2m, and zero values leave the approver defaults in place
+type Tuning struct {
+	MaxAttempts        int
+	RecordTTL          int64
+	DescribePollTime   time.Duration
+	ChangePollTime     time.Duration
+	ValidationPollTime time.Duration
+	DeletionPollTime   time.Duration
+	BackoffMaxDelay    time.Duration
+}
+
+// Validate checks the tuning values are valid
+func (t *Tuning) Validate() error {
+	if t.MaxAttempts < 0 || t.RecordTTL < 0 {
+		return errors.New("MaxAttempts and RecordTTL must not be negative")
+	}
+
+	for _, d := range []time.Duration{t.DescribePollTime, t.ChangePollTime, t.ValidationPollTime, t.DeletionPollTime, t.BackoffMaxDelay} {
+		if d < 0 {
+			return errors.New("poll times and BackoffMaxDelay must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// Options returns approver options for each of the tuning values which are set
+func (t *Tuning) Options() []approver.Option {
+	opts := []approver.Option{}
+
+	if t.MaxAttempts > 0 {
+		opts = append(opts, approver.WithMaxAttempts(t.MaxAttempts))
+	}
+
+	if t.RecordTTL > 0 {
+		opts = append(opts, approver.WithRecordTTL(t.RecordTTL))
+	}
+
+	if t.DescribePollTime > 0 {
+		opts = append(opts, approver.WithDescribePollTime(t.DescribePollTime))
+	}
+
+	if t.ChangePollTime > 0 {
+		opts = append(opts, approver.WithChangePollTime(t.ChangePollTime))
+	}
+
+	if t.ValidationPollTime > 0 {
+		opts = append(opts, approver.WithValidationPollTime(t.ValidationPollTime))
+	}
+
+	if t.DeletionPollTime > 0 {
+		opts = append(opts, approver.WithDeletionPollTime(t.DeletionPollTime))
+	}
+
+	if t.BackoffMaxDelay > 0 {
+		opts = append(opts, approver.WithBackoff(t.BackoffMaxDelay))
+	}
+
+	return opts
+}
+
+// withTuningEnv returns the resource properties with defaults for any tuning values set in the
+// environment, properties on the resource take precedence
+func withTuningEnv(properties map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+
+	for name, key := range tuningEnv {
+		if v := os.Getenv(key); v != "" {
+			merged[name] = v
+		}
+	}
+
+	for k, v := range properties {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// decodeProperties decodes resource properties into the params, cloudformation passes
+// all property values as strings so these are converted to the type of the field
+func decodeProperties(properties map[string]interface{}, params *Params) error {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		Result:           params,
+	})
+	if err != nil {
+		return err
+	}
+
+	return dec.Decode(properties)
+}
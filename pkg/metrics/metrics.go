@@ -0,0 +1,110 @@
+// Package metrics emits metrics using the CloudWatch embedded metric format, these are written as
+// JSON lines which CloudWatch extracts from the lambda logs, so no AWS calls are made
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Namespace the CloudWatch namespace metrics are published to
+const Namespace = "ServerlessACMApprover"
+
+// Dimension names
+const (
+	Operation = "Operation"
+	Outcome   = "Outcome"
+)
+
+// OutcomeSuccess is the outcome of operations which didn't return an error
+const OutcomeSuccess = "Success"
+
+// Unit of a metric
+type Unit string
+
+// Units used by the approver
+const (
+	Milliseconds Unit = "Milliseconds"
+	Count        Unit = "Count"
+)
+
+// Dimensions the values of the dimensions of a metric, keyed by name
+type Dimensions map[string]string
+
+// Metrics writes metrics in the embedded metric format, a nil Metrics discards them
+type Metrics struct {
+	mu        sync.Mutex
+	w         io.Writer
+	namespace string
+	now       func() time.Time
+}
+
+// New creates metrics which are written to w
+func New(w io.Writer) *Metrics {
+	return &Metrics{w: w, namespace: Namespace, now: time.Now}
+}
+
+// Duration records a duration in milliseconds
+func (m *Metrics) Duration(name string, d time.Duration, dims Dimensions) {
+	m.put(name, float64(d)/float64(time.Millisecond), Milliseconds, dims)
+}
+
+// Count records a count
+func (m *Metrics) Count(name string, n int, dims Dimensions) {
+	m.put(name, float64(n), Count, dims)
+}
+
+type document struct {
+	Timestamp         int64       `json:"Timestamp"`
+	CloudWatchMetrics []directive `json:"CloudWatchMetrics"`
+}
+
+type directive struct {
+	Namespace  string       `json:"Namespace"`
+	Dimensions [][]string   `json:"Dimensions"`
+	Metrics    []definition `json:"Metrics"`
+}
+
+type definition struct {
+	Name string `json:"Name"`
+	Unit Unit   `json:"Unit"`
+}
+
+func (m *Metrics) put(name string, value float64, unit Unit, dims Dimensions) {
+	if m == nil {
+		return
+	}
+
+	keys := []string{}
+	fields := map[string]interface{}{}
+
+	for key, v := range dims {
+		keys = append(keys, key)
+		fields[key] = v
+	}
+
+	sort.Strings(keys)
+
+	fields[name] = value
+	fields["_aws"] = document{
+		Timestamp: m.now().UnixNano() / int64(time.Millisecond),
+		CloudWatchMetrics: []directive{{
+			Namespace:  m.namespace,
+			Dimensions: [][]string{keys},
+			Metrics:    []definition{{Name: name, Unit: unit}},
+		}},
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _ = m.w.Write(append(data, '\n'))
+}
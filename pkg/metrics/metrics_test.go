@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Duration(t *testing.T) {
+	assert := require.New(t)
+
+	var output bytes.Buffer
+
+	m := New(&output)
+	m.now = func() time.Time { return time.Unix(1600000000, 0) }
+
+	m.Duration("RequestLatency", 1500*time.Millisecond, Dimensions{Operation: "Create", Outcome: OutcomeSuccess})
+
+	assert.JSONEq(`{
+		"_aws": {
+			"Timestamp": 1600000000000,
+			"CloudWatchMetrics": [{
+				"Namespace": "ServerlessACMApprover",
+				"Dimensions": [["Operation", "Outcome"]],
+				"Metrics": [{"Name": "RequestLatency", "Unit": "Milliseconds"}]
+			}]
+		},
+		"Operation": "Create",
+		"Outcome": "Success",
+		"RequestLatency": 1500
+	}`, output.String())
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+
+	require.NotPanics(t, func() {
+		m.Count("Failures", 1, Dimensions{Operation: "Delete"})
+	})
+}
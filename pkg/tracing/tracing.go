@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel/api/core"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/key"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/exporters/trace/jaeger"
+	"go.opentelemetry.io/otel/exporters/trace/stdout"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// EnvExporter is the environment variable used to select the span exporter
+	EnvExporter = "TRACE_EXPORTER"
+
+	// EnvJaegerEndpoint is the environment variable holding the url of the jaeger collector
+	EnvJaegerEndpoint = "JAEGER_ENDPOINT"
+
+	// EnvJaegerUser and EnvJaegerPassword hold the optional basic auth credentials for the jaeger collector
+	EnvJaegerUser     = "JAEGER_USER"
+	EnvJaegerPassword = "JAEGER_PASSWORD"
+
+	// ExporterNone disables tracing, this is the default
+	ExporterNone = "none"
+
+	// ExporterStdout writes spans to stdout as json, this is intended for local debugging
+	ExporterStdout = "stdout"
+
+	// ExporterJaeger sends spans to the jaeger collector at JAEGER_ENDPOINT over http
+	ExporterJaeger = "jaeger"
+
+	tracerName  = "github.com/wolfeidau/serverless-acm-approver"
+	serviceName = "serverless-acm-approver"
+)
+
+// flush sends any spans buffered by the installed exporter
+var flush = func() {}
+
+// Init installs the global trace provider using the exporter selected by the environment
+func Init() error {
+	provider, flushFn, err := NewProvider(os.Getenv(EnvExporter), os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if provider != nil {
+		global.SetTraceProvider(provider)
+		flush = flushFn
+	}
+
+	return nil
+}
+
+// Flush sends any spans buffered by the exporter installed by Init, lambda freezes the process between
+// invocations so this is called before each invocation returns
+func Flush() {
+	flush()
+}
+
+// NewProvider creates a trace provider using the named exporter along with a function which sends any
+// buffered spans, nil is returned when tracing is disabled
+func NewProvider(exporter string, w io.Writer) (trace.Provider, func(), error) {
+	switch exporter {
+	case "", ExporterNone:
+		return nil, nil, nil
+	case ExporterStdout:
+		exp, err := stdout.NewExporter(stdout.Options{Writer: w})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// spans are exported synchronously so nothing is lost when lambda freezes the process
+		provider, err := sdktrace.NewProvider(sdktrace.WithSyncer(exp))
+
+		return provider, func() {}, err
+	case ExporterJaeger:
+		endpoint := os.Getenv(EnvJaegerEndpoint)
+		if endpoint == "" {
+			return nil, nil, fmt.Errorf("%s is required when %s is %s", EnvJaegerEndpoint, EnvExporter, ExporterJaeger)
+		}
+
+		exp, err := jaeger.NewRawExporter(
+			jaeger.WithCollectorEndpoint(endpoint,
+				jaeger.WithUsername(os.Getenv(EnvJaegerUser)), jaeger.WithPassword(os.Getenv(EnvJaegerPassword))),
+			jaeger.WithProcess(jaeger.Process{ServiceName: serviceName}),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the jaeger exporter buffers spans so they must be flushed before lambda freezes the process
+		provider, err := sdktrace.NewProvider(sdktrace.WithSyncer(exp))
+
+		return provider, exp.Flush, err
+	default:
+		return nil, nil, fmt.Errorf("%s must be one of %s, %s or %s, got %q", EnvExporter, ExporterNone, ExporterStdout, ExporterJaeger, exporter)
+	}
+}
+
+// Start starts a span using the global trace provider
+func Start(ctx context.Context, name string, attrs ...core.KeyValue) (context.Context, trace.Span) {
+	return global.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, then ends the span
+func End(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(ctx, err)
+	}
+
+	span.End()
+}
+
+// AddHandlers adds handlers which trace each AWS SDK request, including any retries
+func AddHandlers(handlers *request.Handlers) {
+	handlers.Validate.PushFrontNamed(request.NamedHandler{Name: "tracing.Start", Fn: startRequest})
+	handlers.Complete.PushBackNamed(request.NamedHandler{Name: "tracing.End", Fn: endRequest})
+}
+
+func startRequest(r *request.Request) {
+	ctx, _ := global.Tracer(tracerName).Start(r.Context(), fmt.Sprintf("%s.%s", r.ClientInfo.ServiceName, r.Operation.Name),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			key.String("aws.service", r.ClientInfo.ServiceName),
+			key.String("aws.operation", r.Operation.Name),
+			key.String("aws.region", aws.StringValue(r.Config.Region)),
+		),
+	)
+
+	r.SetContext(ctx)
+}
+
+func endRequest(r *request.Request) {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+
+	span.SetAttributes(
+		key.String("aws.request_id", r.RequestID),
+		key.Int("aws.retries", r.RetryCount),
+	)
+
+	if r.HTTPResponse != nil {
+		span.SetAttributes(key.Int("http.status_code", r.HTTPResponse.StatusCode))
+	}
+
+	End(ctx, span, r.Error)
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/api/global"
+)
+
+func TestNewProvider(t *testing.T) {
+	assert := require.New(t)
+
+	provider, _, err := NewProvider("", nil)
+	assert.NoError(err)
+	assert.Nil(provider)
+
+	provider, _, err = NewProvider(ExporterNone, nil)
+	assert.NoError(err)
+	assert.Nil(provider)
+
+	_, _, err = NewProvider("xray", nil)
+	assert.EqualError(err, `TRACE_EXPORTER must be one of none, stdout or jaeger, got "xray"`)
+
+	_, _, err = NewProvider(ExporterJaeger, nil)
+	assert.EqualError(err, "JAEGER_ENDPOINT is required when TRACE_EXPORTER is jaeger")
+}
+
+func TestNewProvider_Jaeger(t *testing.T) {
+	assert := require.New(t)
+
+	received := make(chan *http.Request, 1)
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+	}))
+	defer collector.Close()
+
+	os.Setenv(EnvJaegerEndpoint, collector.URL+"/api/traces")
+	defer os.Unsetenv(EnvJaegerEndpoint)
+
+	provider, flush, err := NewProvider(ExporterJaeger, nil)
+	assert.NoError(err)
+
+	_, span := provider.Tracer(tracerName).Start(context.TODO(), "Certificate.Approve")
+	span.End()
+
+	flush()
+
+	r := <-received
+	assert.Equal(http.MethodPost, r.Method)
+	assert.Equal("/api/traces", r.URL.Path)
+	assert.Equal("application/x-thrift", r.Header.Get("Content-Type"))
+}
+
+func TestAddHandlers(t *testing.T) {
+	assert := require.New(t)
+
+	buf := new(bytes.Buffer)
+
+	provider, _, err := NewProvider(ExporterStdout, buf)
+	assert.NoError(err)
+
+	global.SetTraceProvider(provider)
+
+	ctx, span := Start(context.TODO(), "Certificate.Approve")
+
+	handlers := request.Handlers{}
+	AddHandlers(&handlers)
+
+	handlers.Send.PushBack(func(r *request.Request) {
+		r.Error = errors.New("boom")
+	})
+
+	req := request.New(aws.Config{Region: aws.String("us-east-1")}, metadata.ClientInfo{ServiceName: "acm"}, handlers, nil,
+		&request.Operation{Name: "DescribeCertificate"}, nil, nil)
+	req.SetContext(ctx)
+
+	assert.Error(req.Send())
+
+	End(ctx, span, nil)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(lines, 2)
+	assert.Contains(string(lines[0]), `"Name":"acm.DescribeCertificate"`)
+	assert.Contains(string(lines[0]), `"Value":"us-east-1"`)
+	assert.Contains(string(lines[0]), `"Value":"boom"`)
+	assert.Contains(string(lines[1]), `"Name":"Certificate.Approve"`)
+}
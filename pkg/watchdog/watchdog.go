@@ -0,0 +1,258 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+)
+
+// detail types of the events handled by the watchdog
+const (
+	DetailTypeApproachingExpiration = "ACM Certificate Approaching Expiration"
+	DetailTypeRenewalActionRequired = "ACM Certificate Renewal Action Required"
+	DetailTypeScheduled             = "Scheduled Event"
+)
+
+// metric names recorded by the watchdog
+const (
+	metricRepublished = "ValidationRecordsRepublished"
+	metricIneligible  = "RenewalIneligible"
+	metricRepaired    = "ValidationRecordsRepaired"
+)
+
+// Watchdog keeps managed renewal working by republishing the validation records of certificates the
+// approver requested when ACM reports they are due for renewal, and on a schedule restoring any
+// validation records which have drifted from those ACM expects
+type Watchdog struct {
+	certApprover      approver.Certificate
+	newApprover       func(opts ...approver.Option) approver.Certificate
+	options           []approver.Option
+	approvers         map[string]approver.Certificate
+	zones             approver.Zones
+	route53RoleArn    string
+	route53ExternalID string
+	logger            *zerolog.Logger
+	metrics           *metrics.Metrics
+}
+
+// New creates a watchdog, the ROUTE53_ROLE_ARN and ROUTE53_EXTERNAL_ID environment variables select
+// the credentials used to publish the validation records. Records are published into the hosted zones
+// recorded on each certificate, certificates without them use discovery, preferring the HOSTED_ZONE_ID
+// hosted zone for names within it. Certificates which record a different route53 role assume that
+// role, using the ROUTE53_EXTERNAL_ID.
+func New(config ...*aws.Config) *Watchdog {
+	opts := []approver.Option{}
+
+	for _, c := range config {
+		opts = append(opts, approver.WithConfig(c))
+	}
+
+	// metrics are written to stdout using the embedded metric format which cloudwatch extracts from the logs
+	m := metrics.New(os.Stdout)
+
+	opts = append(opts, approver.WithMetrics(m))
+
+	wd := &Watchdog{
+		newApprover:       approver.New,
+		options:           opts,
+		zones:             approver.Zones{PreferredHostedZoneID: os.Getenv("HOSTED_ZONE_ID")},
+		route53RoleArn:    os.Getenv("ROUTE53_ROLE_ARN"),
+		route53ExternalID: os.Getenv("ROUTE53_EXTERNAL_ID"),
+		metrics:           m,
+	}
+
+	wd.certApprover = wd.newApprover(wd.roleOptions(wd.route53RoleArn)...)
+
+	return wd
+}
+
+// WithLogger replaces the logger used by the watchdog, by default the global zerolog logger is used
+func (wd *Watchdog) WithLogger(logger zerolog.Logger) *Watchdog {
+	wd.logger = &logger
+
+	return wd
+}
+
+// Handle is the lambda entry point, it processes the ACM and scheduled events delivered by EventBridge
+func (wd *Watchdog) Handle(ctx context.Context, event events.CloudWatchEvent) error {
+	ctx = wd.eventContext(ctx, event)
+
+	switch event.DetailType {
+	case DetailTypeApproachingExpiration, DetailTypeRenewalActionRequired:
+		return wd.checkRenewals(ctx, event)
+	case DetailTypeScheduled:
+		return wd.repairDrift(ctx)
+	default:
+		zerolog.Ctx(ctx).Warn().Msg("no handler for event")
+		return nil
+	}
+}
+
+// checkRenewals checks the renewal of each certificate referenced by the event
+func (wd *Watchdog) checkRenewals(ctx context.Context, event events.CloudWatchEvent) error {
+	var failed []string
+
+	for _, certificateArn := range event.Resources {
+		err := wd.checkRenewal(ctx, certificateArn)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Str("certificateArn", certificateArn).Msg("failed to check certificate renewal")
+			failed = append(failed, certificateArn)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to check renewal of certificates %v", failed)
+	}
+
+	return nil
+}
+
+// checkRenewal reports certificates ACM won't renew, and republishes the validation records of
+// managed certificates so ACM can validate the renewal
+func (wd *Watchdog) checkRenewal(ctx context.Context, certificateArn string) error {
+	status, err := wd.certApprover.Status(ctx, certificateArn)
+	if err != nil {
+		return err
+	}
+
+	logger := zerolog.Ctx(ctx).With().Str("certificateArn", certificateArn).Str("domainName", status.DomainName).Logger()
+
+	if !status.Eligible() {
+		logger.Warn().Str("type", status.Type).Str("status", status.Status).Strs("inUseBy", status.InUseBy).
+			Time("notAfter", status.NotAfter).Msg("certificate is not eligible for renewal")
+		wd.metrics.Count(metricIneligible, 1, metrics.Dimensions{metrics.Operation: "Renewal"})
+
+		return nil
+	}
+
+	tags, err := wd.certApprover.Tags(ctx, certificateArn)
+	if err != nil {
+		return err
+	}
+
+	if !approver.Managed(tags) {
+		logger.Info().Msg("certificate isn't managed by the approver, leaving it unchanged")
+		return nil
+	}
+
+	logger.Info().Str("renewalStatus", status.RenewalStatus).Msg("republishing validation records")
+
+	err = wd.approverFor(tags).Publish(logger.WithContext(ctx), certificateArn, wd.zonesFor(tags))
+	if err != nil {
+		return err
+	}
+
+	wd.metrics.Count(metricRepublished, 1, metrics.Dimensions{metrics.Operation: "Renewal"})
+
+	return nil
+}
+
+// repairDrift restores the validation records of every managed certificate which are missing from,
+// or changed in, their hosted zone
+func (wd *Watchdog) repairDrift(ctx context.Context) error {
+	certificateArns, err := wd.certApprover.ListManaged(ctx)
+	if err != nil {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().Int("certificates", len(certificateArns)).Msg("checking validation records for drift")
+
+	var (
+		failed   []string
+		repaired int
+	)
+
+	for _, certificateArn := range certificateArns {
+		tags, err := wd.certApprover.Tags(ctx, certificateArn)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Str("certificateArn", certificateArn).Msg("failed to get certificate tags")
+			failed = append(failed, certificateArn)
+			continue
+		}
+
+		repairs, err := wd.approverFor(tags).Repair(ctx, certificateArn, wd.zonesFor(tags))
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Str("certificateArn", certificateArn).Msg("failed to repair validation records")
+			failed = append(failed, certificateArn)
+			continue
+		}
+
+		for _, repair := range repairs {
+			zerolog.Ctx(ctx).Warn().Str("certificateArn", certificateArn).Str("hostedZoneId", repair.HostedZoneID).
+				Str("record", repair.Name).Str("value", repair.Value).Strs("previous", repair.Previous).
+				Bool("missing", repair.Missing()).Msg("restored validation record")
+		}
+
+		repaired += len(repairs)
+	}
+
+	wd.metrics.Count(metricRepaired, repaired, metrics.Dimensions{metrics.Operation: "DriftRepair"})
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to repair validation records of certificates %v", failed)
+	}
+
+	return nil
+}
+
+// zonesFor returns the hosted zones recorded on the certificate when it was requested, otherwise the
+// default zones of the watchdog
+func (wd *Watchdog) zonesFor(tags map[string]string) approver.Zones {
+	if value, ok := tags[approver.TagZones]; ok {
+		return approver.ParseZonesTag(value)
+	}
+
+	return wd.zones
+}
+
+// approverFor returns an approver using the route53 role recorded on the certificate when it was
+// requested, otherwise the default approver of the watchdog
+func (wd *Watchdog) approverFor(tags map[string]string) approver.Certificate {
+	roleArn, ok := tags[approver.TagRoute53RoleArn]
+	if !ok || roleArn == wd.route53RoleArn {
+		return wd.certApprover
+	}
+
+	if wd.approvers == nil {
+		wd.approvers = map[string]approver.Certificate{}
+	}
+
+	certApprover, ok := wd.approvers[roleArn]
+	if !ok {
+		certApprover = wd.newApprover(wd.roleOptions(roleArn)...)
+		wd.approvers[roleArn] = certApprover
+	}
+
+	return certApprover
+}
+
+// roleOptions returns the options of the watchdog, assuming the role for route53 calls when it is set
+func (wd *Watchdog) roleOptions(roleArn string) []approver.Option {
+	opts := append([]approver.Option{}, wd.options...)
+
+	if roleArn != "" {
+		opts = append(opts, approver.WithRoute53Role(roleArn, wd.route53ExternalID))
+	}
+
+	return opts
+}
+
+// eventContext adds a logger with the fields identifying the event to the context
+func (wd *Watchdog) eventContext(ctx context.Context, event events.CloudWatchEvent) context.Context {
+	logger := log.Logger
+	if wd.logger != nil {
+		logger = *wd.logger
+	}
+
+	logger = logger.With().Str("EventID", event.ID).Str("DetailType", event.DetailType).Logger()
+
+	return logger.WithContext(ctx)
+}
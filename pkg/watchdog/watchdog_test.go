@@ -0,0 +1,207 @@
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wolfeidau/serverless-acm-approver/mocks"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/approver"
+	"github.com/wolfeidau/serverless-acm-approver/pkg/metrics"
+)
+
+var expiringEvent = events.CloudWatchEvent{
+	ID:         "abc123",
+	DetailType: DetailTypeApproachingExpiration,
+	Source:     "aws.acm",
+	Resources:  []string{"ghi789"},
+}
+
+func TestHandle_Republish(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{CertificateArn: "ghi789", RenewalEligibility: "ELIGIBLE"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "ghi789").Return(map[string]string{approver.TagStackID: "stack"}, nil)
+	cert.EXPECT().Publish(gomock.Any(), "ghi789", approver.Zones{PreferredHostedZoneID: "Z123"}).Return(nil)
+
+	var output bytes.Buffer
+
+	wd := &Watchdog{certApprover: cert, zones: approver.Zones{PreferredHostedZoneID: "Z123"}, metrics: metrics.New(&output)}
+
+	err := wd.Handle(context.TODO(), expiringEvent)
+	assert.NoError(err)
+	assert.Contains(output.String(), `"Operation":"Renewal","ValidationRecordsRepublished":1`)
+}
+
+func TestHandle_RepublishTaggedZones(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	// the zones selected when the certificate was requested are used rather than the watchdog zone
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{CertificateArn: "ghi789", RenewalEligibility: "ELIGIBLE"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "ghi789").Return(map[string]string{
+		approver.TagStackID: "stack", approver.TagZones: "Z456 t.io=Z789",
+	}, nil)
+	cert.EXPECT().Publish(gomock.Any(), "ghi789", approver.Zones{HostedZoneID: "Z456", Domains: map[string]string{"t.io": "Z789"}}).Return(nil)
+
+	wd := &Watchdog{certApprover: cert, zones: approver.Zones{PreferredHostedZoneID: "Z123"}, metrics: metrics.New(&bytes.Buffer{})}
+
+	err := wd.Handle(context.TODO(), expiringEvent)
+	assert.NoError(err)
+}
+
+func TestHandle_Unmanaged(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{CertificateArn: "ghi789", RenewalEligibility: "ELIGIBLE"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "ghi789").Return(map[string]string{"Team": "platform"}, nil)
+
+	wd := &Watchdog{certApprover: cert}
+
+	err := wd.Handle(context.TODO(), expiringEvent)
+	assert.NoError(err)
+}
+
+func TestHandle_Ineligible(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{CertificateArn: "ghi789", RenewalEligibility: "INELIGIBLE"}, nil)
+
+	var output bytes.Buffer
+
+	wd := &Watchdog{certApprover: cert, metrics: metrics.New(&output)}
+
+	err := wd.Handle(context.TODO(), expiringEvent)
+	assert.NoError(err)
+	assert.Contains(output.String(), `"Operation":"Renewal","RenewalIneligible":1`)
+}
+
+func TestHandle_Errors(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(nil, errors.New("boom"))
+	cert.EXPECT().Status(gomock.Any(), "jkl012").Return(&approver.CertificateStatus{CertificateArn: "jkl012", RenewalEligibility: "ELIGIBLE"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "jkl012").Return(map[string]string{approver.TagStackID: "stack"}, nil)
+	cert.EXPECT().Publish(gomock.Any(), "jkl012", gomock.Any()).Return(nil)
+
+	wd := &Watchdog{certApprover: cert}
+
+	event := expiringEvent
+	event.Resources = []string{"ghi789", "jkl012"}
+
+	err := wd.Handle(context.TODO(), event)
+	assert.EqualError(err, "failed to check renewal of certificates [ghi789]")
+}
+
+func TestHandle_UnknownEvent(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wd := &Watchdog{certApprover: mocks.NewMockCertificate(ctrl)}
+
+	event := expiringEvent
+	event.DetailType = "ACM Certificate Available"
+
+	err := wd.Handle(context.TODO(), event)
+	assert.NoError(err)
+}
+
+func TestHandle_RepublishRecordedRole(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+	roleCert := mocks.NewMockCertificate(ctrl)
+
+	// the hosted zones are in another account so are published using the role recorded on the certificate
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{CertificateArn: "ghi789", RenewalEligibility: "ELIGIBLE"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "ghi789").Return(map[string]string{
+		approver.TagStackID: "stack", approver.TagZones: "Z456", approver.TagRoute53RoleArn: "arn:dns-role",
+	}, nil)
+	roleCert.EXPECT().Publish(gomock.Any(), "ghi789", approver.Zones{HostedZoneID: "Z456", Domains: map[string]string{}}).Return(nil)
+
+	var roleApprovers int
+
+	wd := &Watchdog{certApprover: cert, route53RoleArn: "arn:watchdog-role", metrics: metrics.New(&bytes.Buffer{}),
+		newApprover: func(opts ...approver.Option) approver.Certificate {
+			roleApprovers++
+			return roleCert
+		}}
+
+	err := wd.Handle(context.TODO(), expiringEvent)
+	assert.NoError(err)
+	assert.Equal(1, roleApprovers)
+
+	// certificates recorded with the role of the watchdog use the default approver
+	cert.EXPECT().Status(gomock.Any(), "ghi789").Return(&approver.CertificateStatus{CertificateArn: "ghi789", RenewalEligibility: "ELIGIBLE"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "ghi789").Return(map[string]string{
+		approver.TagStackID: "stack", approver.TagZones: "Z123", approver.TagRoute53RoleArn: "arn:watchdog-role",
+	}, nil)
+	cert.EXPECT().Publish(gomock.Any(), "ghi789", approver.Zones{HostedZoneID: "Z123", Domains: map[string]string{}}).Return(nil)
+
+	err = wd.Handle(context.TODO(), expiringEvent)
+	assert.NoError(err)
+	assert.Equal(1, roleApprovers)
+}
+
+func TestHandle_RepairDrift(t *testing.T) {
+	assert := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert := mocks.NewMockCertificate(ctrl)
+
+	cert.EXPECT().ListManaged(gomock.Any()).Return([]string{"ghi789", "jkl012", "mno345"}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "ghi789").Return(map[string]string{approver.TagStackID: "stack", approver.TagZones: "Z123"}, nil)
+	cert.EXPECT().Repair(gomock.Any(), "ghi789", approver.Zones{HostedZoneID: "Z123", Domains: map[string]string{}}).Return([]approver.RecordRepair{
+		{HostedZoneID: "Z123", Name: "_a.t.co.", Type: "CNAME", Value: "abc"},
+		{HostedZoneID: "Z123", Name: "_b.t.co.", Type: "CNAME", Value: "def", Previous: []string{"old"}},
+	}, nil)
+	cert.EXPECT().Tags(gomock.Any(), "jkl012").Return(map[string]string{approver.TagStackID: "stack"}, nil)
+	cert.EXPECT().Repair(gomock.Any(), "jkl012", approver.Zones{PreferredHostedZoneID: "Z999"}).Return(nil, errors.New("boom"))
+	cert.EXPECT().Tags(gomock.Any(), "mno345").Return(nil, errors.New("boom"))
+
+	var output bytes.Buffer
+
+	wd := &Watchdog{certApprover: cert, zones: approver.Zones{PreferredHostedZoneID: "Z999"}, metrics: metrics.New(&output)}
+
+	event := events.CloudWatchEvent{ID: "abc123", DetailType: DetailTypeScheduled, Source: "aws.events"}
+
+	err := wd.Handle(context.TODO(), event)
+	assert.EqualError(err, "failed to repair validation records of certificates [jkl012 mno345]")
+	assert.Contains(output.String(), `"Operation":"DriftRepair","ValidationRecordsRepaired":2`)
+}